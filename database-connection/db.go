@@ -3,111 +3,58 @@ package main
 // Dependencies
 // MySQL Driver: go get -u github.com/go-sql-driver/mysql
 import (
+	"context"
 	"database/sql"
-	_ "github.com/go-sql-driver/mysql"
+	"golang-library/database-connection/dbconfig"
+	"golang-library/database-connection/repository"
 	"log"
+	"time"
 )
 
-const (
-	dataSourceName = "root:root@tcp(localhost:3012)/golang_playground"
-)
-
-type User struct {
-	Id int
-	FirstName string
-	LastName string
-	EmailAddress string
-	NullValue sql.NullInt64
-}
-
-func getDb() *sql.DB {
-	db, err := sql.Open("mysql", dataSourceName)
-	if err != nil {
-		log.Fatalln(err)
-	}
-
-	if err = db.Ping(); err != nil {
-		log.Fatalf("Unable to connect to database: %s", err)
-	}
-
-	return db
+// defaultConfig holds the settings not given by the file named by DB_CONFIG_FILE
+// or by the DB_* environment variables, see package dbconfig.
+var defaultConfig = dbconfig.Config{
+	Driver:          "mysql",
+	DSN:             "root:root@tcp(localhost:3012)/golang_playground?parseTime=true",
+	MaxOpenConns:    10,
+	ConnMaxLifetime: dbconfig.Duration(5 * time.Minute),
+	ConnectTimeout:  dbconfig.Duration(5 * time.Second),
 }
 
 func main() {
-	db := getDb()
-
-	users := make(chan User)
-	go getUsers(db, users)
-
-	for user := range users {
-		log.Println(user)
-	}
-
-	createUser(db)
+	ctx := context.Background()
 
-	err := db.Close()
+	cfg, err := dbconfig.Load(defaultConfig, "DB_")
 	if err != nil {
-		log.Fatalf("Unable to close database connection: %s", err)
+		log.Fatalln(err)
 	}
-}
+	log.Printf("Connecting to %s", cfg)
 
-func getUsers(db *sql.DB, users chan User) {
-	query := `
-		SELECT
-			id,
-			first_name,
-			last_name,
-			email,
-		    null_column
-		FROM
-			golang_playground.users
-		WHERE
-			id >= ?
-	`
-	stmt, err := db.Prepare(query)
+	// The schema is managed by the migrate package. Apply it first with:
+	//   go run ./migrate-db up
+	users, err := repository.Open(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Unable to prepare query: %s. Error: %s", query, err)
+		log.Fatalln(err)
 	}
+	defer users.Close()
 
-	rows, err := stmt.Query(35)
+	list, err := users.List(ctx, repository.ListOptions{FromID: 35})
 	if err != nil {
-		log.Fatalf("Error executing query: %s. Error: %s", query, err)
-	}
-
-	for rows.Next() {
-		var user User
-		if err := rows.Scan(&user.Id, &user.FirstName, &user.LastName, &user.EmailAddress, &user.NullValue); err != nil {
-			log.Fatalf("Unable to scan row result: %s", err)
-		}
-
-		users <- user
+		log.Fatalln(err)
 	}
-
-	close(users)
-
-	err = rows.Close()
-	if err != nil {
-		log.Printf("Unable to close Rows after query result. Error: %s", err)
+	for _, user := range list {
+		log.Println(user)
 	}
-}
 
-func createUser(db *sql.DB) {
-	insert := `
-		INSERT INTO
-			golang_playground.users (first_name, last_name, email, null_column)
-		VALUES (?, ?, ?, ?)
-	`
-
-	stmt, err := db.Prepare(insert)
-	if err != nil {
-		log.Fatalf("Unable to prepare query: %s. Error: %s", insert, err)
+	user := repository.User{
+		FirstName:    "test insert",
+		LastName:     "test insert last name",
+		EmailAddress: "test@test.com",
+		NullValue:    sql.NullInt64{},
 	}
-
-	res, err := stmt.Exec("test insert", "test insert last name", "test@test.com", sql.NullInt64{})
-	if err != nil {
-		log.Fatalf("Unable to execute query: %s. Error: %s", insert, err)
+	if err := users.Create(ctx, &user); err != nil {
+		log.Fatalln(err)
 	}
 
-	log.Println(res.LastInsertId())
-	log.Println(res.RowsAffected())
-}
\ No newline at end of file
+	log.Printf("Created User %d", user.ID)
+}
@@ -0,0 +1,122 @@
+package dbutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// Preparer prepares statements. It is implemented by *sqlx.DB, *sqlx.Tx and
+// *Tx.
+type Preparer interface {
+	PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error)
+}
+
+// Rows iterates over the result of a query one row at a time, scanning each
+// into a T, a struct with db tags, with sqlx's StructScan. Unlike collecting
+// the whole result, only one row is held in memory, and unlike streaming it
+// through a channel, nothing is left running when the caller stops early:
+//
+//	rows, err := dbutil.Query[repository.User](ctx, db, query, 35)
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//
+//	for rows.Next() {
+//		u := rows.Value()
+//	}
+//	return rows.Err()
+type Rows[T any] struct {
+	ctx   context.Context
+	stmt  *sqlx.Stmt
+	rows  *sqlx.Rows
+	value T
+	err   error
+	done  bool
+}
+
+// Query prepares query on db and runs it with args. The returned Rows must be
+// closed, which also closes the statement, unless they are iterated until
+// Next returns false.
+func Query[T any](ctx context.Context, db Preparer, query string, args ...any) (*Rows[T], error) {
+	stmt, err := db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare query: %s: %w", query, err)
+	}
+
+	rows, err := stmt.QueryxContext(ctx, args...)
+	if err != nil {
+		stmt.Close()
+		return nil, fmt.Errorf("unable to execute query: %s: %w", query, err)
+	}
+
+	return &Rows[T]{ctx: ctx, stmt: stmt, rows: rows}, nil
+}
+
+// Next scans the next row, returning false once there are none left or an
+// error occurred, in which case Err reports it. The Rows are closed when Next
+// returns false. Next stops early once the context passed to Query is done.
+func (r *Rows[T]) Next() bool {
+	if r.done {
+		return false
+	}
+
+	if err := r.ctx.Err(); err != nil {
+		r.fail(err)
+		return false
+	}
+
+	if !r.rows.Next() {
+		r.fail(r.rows.Err())
+		return false
+	}
+
+	var value T
+	if err := r.rows.StructScan(&value); err != nil {
+		r.fail(fmt.Errorf("unable to scan row result: %w", err))
+		return false
+	}
+	r.value = value
+
+	return true
+}
+
+// Value returns the row scanned by the last call to Next.
+func (r *Rows[T]) Value() T {
+	return r.value
+}
+
+// Err returns the error that ended the iteration, if any.
+func (r *Rows[T]) Err() error {
+	return r.err
+}
+
+// Close closes the rows and the statement. It is safe to call several times
+// and after Next returned false.
+func (r *Rows[T]) Close() error {
+	if r.done {
+		return nil
+	}
+	r.done = true
+
+	var errs []error
+	if err := r.rows.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("unable to close rows: %w", err))
+	}
+	if err := r.stmt.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("unable to close statement: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// fail ends the iteration with err, which may be nil, and closes the Rows.
+func (r *Rows[T]) fail(err error) {
+	closeErr := r.Close()
+	if err == nil {
+		err = closeErr
+	}
+	r.err = err
+}
@@ -0,0 +1,171 @@
+package dbutil_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"golang-library/database-connection/dbutil"
+	"testing"
+	"time"
+)
+
+type user struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+// collect iterates over rows until Next returns false.
+func collect(rows *dbutil.Rows[user]) []string {
+	var got []string
+	for rows.Next() {
+		got = append(got, rows.Value().Name)
+	}
+
+	return got
+}
+
+// assertReleased checks that the only connection of db is free again, which
+// it is not while Rows or their statement are open.
+func assertReleased(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatalf("unable to use the connection: %v", err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, "a", "b", "c")
+	db.SetMaxOpenConns(1)
+
+	rows, err := dbutil.Query[user](ctx, db, "SELECT id, name FROM users WHERE id >= ? ORDER BY id", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := collect(rows); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Errorf("got names %v, want [b c]", got)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if rows.Next() {
+		t.Error("got Next true after the last row")
+	}
+	for i := 0; i < 2; i++ {
+		if err := rows.Close(); err != nil {
+			t.Errorf("got error %v closing finished Rows", err)
+		}
+	}
+
+	// The Rows released the connection when Next returned false.
+	assertReleased(t, db)
+}
+
+func TestQueryClose(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, "a", "b", "c")
+	db.SetMaxOpenConns(1)
+
+	rows, err := dbutil.Query[user](ctx, db, "SELECT id, name FROM users ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !rows.Next() || rows.Value().Name != "a" {
+		t.Fatalf("got first row %+v, want a", rows.Value())
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Errorf("got error %v closing Rows twice", err)
+	}
+	if rows.Next() {
+		t.Error("got Next true after Close")
+	}
+	if err := rows.Err(); err != nil {
+		t.Errorf("got error %v after Close, want none", err)
+	}
+
+	assertReleased(t, db)
+}
+
+func TestQueryErrors(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, "a")
+	db.SetMaxOpenConns(1)
+
+	if _, err := dbutil.Query[user](ctx, db, "SELECT id, name FROM missing"); err == nil {
+		t.Error("got no error querying a missing table")
+	}
+
+	// The email column has no field in user, so scanning fails.
+	rows, err := dbutil.Query[user](ctx, db, "SELECT id, name, name AS email FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Next() {
+		t.Error("got Next true for a row that cannot be scanned")
+	}
+	if rows.Err() == nil {
+		t.Error("got no error for a row that cannot be scanned")
+	}
+
+	assertReleased(t, db)
+}
+
+func TestQueryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := openSQLite(t, "a", "b", "c")
+	db.SetMaxOpenConns(1)
+
+	rows, err := dbutil.Query[user](ctx, db, "SELECT id, name FROM users ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+
+	cancel()
+	if rows.Next() {
+		t.Error("got Next true after the context was cancelled")
+	}
+	if err := rows.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+
+	assertReleased(t, db)
+}
+
+func TestQueryTx(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, "a")
+
+	err := dbutil.WithTx(ctx, db, func(tx *dbutil.Tx) error {
+		if err := insert(ctx, tx, "b"); err != nil {
+			return err
+		}
+
+		rows, err := dbutil.Query[user](ctx, tx, "SELECT id, name FROM users ORDER BY id")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		if got := collect(rows); len(got) != 2 {
+			t.Errorf("got names %v, want the row inserted by the transaction too", got)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,166 @@
+// Package dbutil holds helpers shared by the programs and packages working
+// with SQL databases through sqlx.
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"math/rand"
+	"modernc.org/sqlite"
+	"time"
+)
+
+// TxOptions configures the transactions started by WithTxOptions.
+type TxOptions struct {
+	// Isolation and ReadOnly are passed to the driver, which may reject
+	// levels it does not support. The zero Isolation is the driver's default.
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+
+	// MaxAttempts is the number of times a transaction failing with a
+	// deadlock or a busy database is run, including the first. It defaults to
+	// three; one disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for every
+	// following one and jittered. It defaults to 50 milliseconds.
+	BaseDelay time.Duration
+}
+
+// Tx is a transaction started by WithTx.
+type Tx struct {
+	*sqlx.Tx
+
+	savepoints int
+}
+
+// WithTx runs fn in a transaction of db with the default TxOptions. See
+// WithTxOptions.
+func WithTx(ctx context.Context, db *sqlx.DB, fn func(tx *Tx) error) error {
+	return WithTxOptions(ctx, db, TxOptions{}, fn)
+}
+
+// WithTxOptions runs fn in a transaction of db, committing it if fn returns
+// nil and rolling it back if fn returns an error or panics.
+//
+// A transaction failing because of a MySQL deadlock or lock wait timeout, or
+// because the SQLite database is busy, is rolled back and run again, up to
+// opts.MaxAttempts times. fn must therefore leave no trace outside the
+// transaction until WithTxOptions returns.
+func WithTxOptions(ctx context.Context, db *sqlx.DB, opts TxOptions, fn func(tx *Tx) error) error {
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	delay := opts.BaseDelay
+	if delay <= 0 {
+		delay = 50 * time.Millisecond
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, opts, fn)
+		if err == nil || attempt >= attempts || !Retryable(err) {
+			return err
+		}
+
+		// Wait between half and all of the delay, so that the transactions
+		// that collided do not collide again.
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+		delay *= 2
+	}
+}
+
+// runTx runs fn in a single transaction.
+func runTx(ctx context.Context, db *sqlx.DB, opts TxOptions, fn func(tx *Tx) error) error {
+	sqlTx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&Tx{Tx: sqlTx}); err != nil {
+		if rollbackErr := sqlTx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("unable to roll back transaction: %w", rollbackErr))
+		}
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// WithSavepoint runs fn in a savepoint of tx, releasing it if fn returns nil
+// and rolling back to it if fn returns an error or panics, in which case the
+// rest of the transaction can go on. Savepoints nest.
+func (tx *Tx) WithSavepoint(ctx context.Context, fn func(tx *Tx) error) error {
+	tx.savepoints++
+	name := fmt.Sprintf("sp_%d", tx.savepoints)
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("unable to create savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("unable to roll back to savepoint: %w", rollbackErr))
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("unable to release savepoint: %w", err)
+	}
+
+	return nil
+}
+
+// MySQL and SQLite error codes after which a transaction can be retried.
+const (
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+	sqliteBusy           = 5
+)
+
+// Retryable reports whether err is caused by a MySQL deadlock or lock wait
+// timeout, or by a busy SQLite database, after which running the transaction
+// again may succeed.
+func Retryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// Extended result codes, such as SQLITE_BUSY_SNAPSHOT, keep the
+		// primary code in their low byte.
+		return sqliteErr.Code()&0xff == sqliteBusy
+	}
+
+	return false
+}
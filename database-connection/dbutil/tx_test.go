@@ -0,0 +1,244 @@
+package dbutil_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"golang-library/database-connection/dbutil"
+	_ "modernc.org/sqlite"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openSQLite opens a SQLite database in a file of a temporary directory, with
+// a users table holding the given names.
+func openSQLite(t *testing.T, names ...string) *sqlx.DB {
+	t.Helper()
+
+	db, err := sqlx.Open("sqlite", filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name text NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if _, err := db.Exec("INSERT INTO users (name) VALUES (?)", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+// names returns the names in the users table, in ID order.
+func names(t *testing.T, db *sqlx.DB) []string {
+	t.Helper()
+
+	var got []string
+	if err := db.Select(&got, "SELECT name FROM users ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+
+	return got
+}
+
+func assertNames(t *testing.T, db *sqlx.DB, want ...string) {
+	t.Helper()
+
+	got := names(t, db)
+	if len(got) != len(want) {
+		t.Fatalf("got names %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got names %v, want %v", got, want)
+		}
+	}
+}
+
+func insert(ctx context.Context, tx *dbutil.Tx, name string) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO users (name) VALUES (?)", name)
+	return err
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name    string
+		fn      func(tx *dbutil.Tx) error
+		wantErr error
+		want    []string
+	}{
+		{
+			name: "commit",
+			fn: func(tx *dbutil.Tx) error {
+				return insert(ctx, tx, "b")
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "rollback on error",
+			fn: func(tx *dbutil.Tx) error {
+				if err := insert(ctx, tx, "b"); err != nil {
+					return err
+				}
+				return errFailed
+			},
+			wantErr: errFailed,
+			want:    []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openSQLite(t, "a")
+
+			if err := dbutil.WithTx(ctx, db, tt.fn); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			assertNames(t, db, tt.want...)
+		})
+	}
+}
+
+func TestWithTxPanic(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, "a")
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("got panic %v, want boom", p)
+			}
+		}()
+
+		dbutil.WithTx(ctx, db, func(tx *dbutil.Tx) error {
+			if err := insert(ctx, tx, "b"); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+
+	assertNames(t, db, "a")
+}
+
+func TestWithSavepoint(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	errFailed := errors.New("failed")
+
+	err := dbutil.WithTx(ctx, db, func(tx *dbutil.Tx) error {
+		if err := insert(ctx, tx, "a"); err != nil {
+			return err
+		}
+
+		err := tx.WithSavepoint(ctx, func(tx *dbutil.Tx) error {
+			if err := insert(ctx, tx, "b"); err != nil {
+				return err
+			}
+
+			// The inner savepoint is rolled back, the outer one released.
+			err := tx.WithSavepoint(ctx, func(tx *dbutil.Tx) error {
+				if err := insert(ctx, tx, "c"); err != nil {
+					return err
+				}
+				return errFailed
+			})
+			if !errors.Is(err, errFailed) {
+				t.Errorf("got inner savepoint error %v, want %v", err, errFailed)
+			}
+
+			return insert(ctx, tx, "d")
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithSavepoint(ctx, func(tx *dbutil.Tx) error {
+			if err := insert(ctx, tx, "e"); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got savepoint error %v, want %v", err, errFailed)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertNames(t, db, "a", "b", "d")
+}
+
+func TestWithTxOptionsRetry(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, "a")
+
+	// Another connection holds the write lock until the first attempt has
+	// failed with SQLITE_BUSY.
+	holder, err := db.DB.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Close()
+	if _, err := holder.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := 0
+	err = dbutil.WithTxOptions(ctx, db, dbutil.TxOptions{BaseDelay: time.Millisecond}, func(tx *dbutil.Tx) error {
+		attempts++
+		if attempts == 2 {
+			if _, err := holder.ExecContext(ctx, "ROLLBACK"); err != nil {
+				t.Error(err)
+			}
+		}
+
+		return insert(ctx, tx, "b")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+
+	assertNames(t, db, "a", "b")
+}
+
+func TestWithTxOptionsMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, "a")
+
+	holder, err := db.DB.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Close()
+	if _, err := holder.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatal(err)
+	}
+	defer holder.ExecContext(ctx, "ROLLBACK")
+
+	attempts := 0
+	err = dbutil.WithTxOptions(ctx, db, dbutil.TxOptions{MaxAttempts: 3, BaseDelay: time.Millisecond}, func(tx *dbutil.Tx) error {
+		attempts++
+		return insert(ctx, tx, "b")
+	})
+	if !dbutil.Retryable(err) {
+		t.Fatalf("got error %v, want a busy database", err)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	_ "github.com/go-sql-driver/mysql"
+	"golang-library/database-connection/dbconfig"
+	"golang-library/database-connection/migrate"
+	"log"
+	_ "modernc.org/sqlite"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// defaultConfig holds the settings not given by the file named by DB_CONFIG_FILE,
+// the DB_* environment variables or the flags.
+var defaultConfig = dbconfig.Config{
+	Driver:         "mysql",
+	DSN:            "root:root@tcp(localhost:3012)/golang_playground",
+	ConnectTimeout: dbconfig.Duration(5 * time.Second),
+}
+
+func main() {
+	// Apply, roll back or inspect the schema of the database configured by
+	// DB_CONFIG_FILE and the DB_* environment variables, see package dbconfig.
+	// The MySQL database itself must exist, see ../golang_playground.sql:
+	//   go run . up
+	//   go run . down 1
+	//   DB_CONFIG_FILE=../config.example.yaml go run . status
+	//   go run . -driver sqlite -dsn users.db status
+	driver := flag.String("driver", "", "mysql or sqlite, overriding the configured driver")
+	dsn := flag.String("dsn", "", "data source name of the database, overriding the configured one")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] up | down [steps] | status\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "up", "down", "status":
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := dbconfig.Load(defaultConfig, "DB_")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *driver != "" {
+		cfg.Driver = *driver
+	}
+	if *dsn != "" {
+		cfg.DSN = *dsn
+	}
+	log.Printf("Connecting to %s", cfg)
+
+	db, err := cfg.Open(ctx)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer db.Close()
+
+	m := migrate.Migrator{DB: db.DB, Dialect: cfg.Driver}
+
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			log.Printf("Applied %s", mig)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(applied) == 0 {
+			log.Println("Schema is up to date.")
+		}
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			if steps, err = strconv.Atoi(flag.Arg(1)); err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps %q", flag.Arg(1))
+			}
+		}
+
+		rolledBack, err := m.Down(ctx, steps)
+		for _, mig := range rolledBack {
+			log.Printf("Rolled back %s", mig)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			if s.Missing {
+				applied += " (migration missing)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		w.Flush()
+	}
+}
@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"modernc.org/sqlite"
+	"time"
+)
+
+// dialect holds what differs between the databases a Migrator supports.
+type dialect struct {
+	createTable string
+
+	// atomic reports whether a run is rolled back as a whole when it fails.
+	atomic bool
+
+	// lock takes the migration lock on conn, waiting up to timeout for it,
+	// and returns the function releasing it once the run has finished with
+	// the given error.
+	lock func(ctx context.Context, conn *sql.Conn, timeout time.Duration) (unlock func(error) error, err error)
+}
+
+var dialects = map[string]dialect{
+	"mysql": {
+		createTable: `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version bigint NOT NULL,
+				name varchar(255) NOT NULL,
+				applied_at varchar(35) NOT NULL,
+				PRIMARY KEY (version)
+			)
+		`,
+		lock: mysqlLock,
+	},
+	"sqlite": {
+		createTable: `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version integer NOT NULL PRIMARY KEY,
+				name varchar(255) NOT NULL,
+				applied_at varchar(35) NOT NULL
+			)
+		`,
+		atomic: true,
+		lock:   sqliteLock,
+	},
+}
+
+// lockName is the name of the MySQL advisory lock held by a run.
+const lockName = "schema_migrations"
+
+// unlockTimeout bounds the statements releasing the migration lock, which
+// run even once the context of the run is done.
+const unlockTimeout = 5 * time.Second
+
+// mysqlLock takes a named advisory lock, released when the connection
+// closes if not before. MySQL commits schema changes implicitly, so the run
+// cannot be made atomic.
+func mysqlLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) (func(error) error, error) {
+	var acquired sql.NullInt64
+	seconds := int64(math.Ceil(timeout.Seconds()))
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, seconds).Scan(&acquired); err != nil {
+		return nil, fmt.Errorf("unable to take migration lock: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return nil, ErrLocked
+	}
+
+	return func(error) error {
+		ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+
+		if _, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+			// Closing the connection releases the lock instead.
+			discard(conn)
+			return fmt.Errorf("unable to release migration lock: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+// SQLite result codes of a database locked by another connection, or by
+// another statement of the same one.
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+)
+
+// busy reports whether err is caused by a busy or locked SQLite database.
+func busy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	// Extended result codes, such as SQLITE_BUSY_SNAPSHOT, keep the primary
+	// code in their low byte.
+	code := sqliteErr.Code() & 0xff
+	return code == sqliteBusy || code == sqliteLocked
+}
+
+// sqliteLock starts an immediate transaction, which takes the database write
+// lock for the whole run and makes it atomic. The connection's busy timeout
+// is set to timeout while waiting for the lock, and restored on unlock.
+func sqliteLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) (func(error) error, error) {
+	var busyTimeout int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+		return nil, fmt.Errorf("unable to get busy timeout: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA busy_timeout = %d", timeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("unable to set busy timeout: %w", err)
+	}
+	restore := func(ctx context.Context) error {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA busy_timeout = %d", busyTimeout)); err != nil {
+			discard(conn)
+			return fmt.Errorf("unable to restore busy timeout: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		restoreCtx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+
+		restore(restoreCtx)
+		if busy(err) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, err)
+		}
+		return nil, err
+	}
+
+	return func(runErr error) error {
+		ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+
+		var err error
+		if runErr == nil {
+			if _, commitErr := conn.ExecContext(ctx, "COMMIT"); commitErr != nil {
+				err = fmt.Errorf("unable to commit migrations: %w", commitErr)
+			}
+		}
+		if runErr != nil || err != nil {
+			// The connection goes back to the pool once the run is over, so
+			// it must not be left inside the transaction.
+			if _, rollbackErr := conn.ExecContext(ctx, "ROLLBACK"); rollbackErr != nil {
+				discard(conn)
+				return err
+			}
+		}
+
+		if restoreErr := restore(ctx); restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+
+		return err
+	}, nil
+}
+
+// discard makes conn be closed rather than returned to the pool, dropping
+// whatever lock or transaction it still holds.
+func discard(conn *sql.Conn) {
+	conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+}
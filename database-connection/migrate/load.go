@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations
+var embedded embed.FS
+
+// fileName matches migration scripts such as 0001_create_users.up.sql.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migrations returns the embedded Migrations of the dialect, "mysql" or
+// "sqlite".
+func Migrations(dialect string) ([]Migration, error) {
+	if _, ok := dialects[dialect]; !ok {
+		return nil, fmt.Errorf("migrate: unknown dialect %q", dialect)
+	}
+
+	return Load(embedded, path.Join("migrations", dialect))
+}
+
+// Load reads the Migrations in dir of fsys. Every Migration has a
+// <version>_<name>.up.sql script and, if it can be rolled back, a
+// <version>_<name>.down.sql one. Other files are ignored.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		match := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", e.Name(), err)
+		}
+
+		script, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read migration: %w", err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("migrate: migration version %d has two names, %s and %s", version, mig.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			mig.Up = string(script)
+		} else {
+			mig.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migrate: migration %s has no up script", mig)
+		}
+		migrations = append(migrations, *mig)
+	}
+
+	return migrations, nil
+}
+
+// execScript executes the statements of a migration script one by one, as
+// not every driver accepts several statements at once.
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, stmt := range statements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// statements splits a script into statements at semicolons ending a line,
+// dropping comment lines.
+func statements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if s := strings.TrimSpace(current.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+
+	return stmts
+}
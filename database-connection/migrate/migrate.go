@@ -0,0 +1,312 @@
+// Package migrate applies versioned schema migrations to the MySQL and SQLite
+// databases used by the other database-connection packages.
+//
+// Migrations are numbered SQL scripts, one per schema change, with an up
+// script applying the change and an optional down script reverting it:
+//
+//	migrations/mysql/0001_create_users.up.sql
+//	migrations/mysql/0001_create_users.down.sql
+//
+// The migrations of every dialect are embedded in the package and returned
+// by Migrations. The versions applied to a database are tracked in its
+// schema_migrations table, and concurrent runs against the same database are
+// serialised by a lock, so that every program can safely migrate on start:
+//
+//	m := migrate.Migrator{DB: db, Dialect: "sqlite"}
+//	applied, err := m.Up(ctx)
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrLocked is returned when another run holds the migration lock for
+	// longer than the LockTimeout.
+	ErrLocked = errors.New("migrate: database is locked by another migration run")
+
+	// ErrIrreversible is returned when rolling back a Migration without a down
+	// script.
+	ErrIrreversible = errors.New("migrate: migration cannot be rolled back")
+)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version int64
+	Name    string
+
+	// Up applies the change and Down, if not empty, reverts it. Statements
+	// are separated by semicolons at the end of a line.
+	Up, Down string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status describes whether a Migration is applied to a database.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+
+	// Missing reports a version applied to the database that is not among
+	// the Migrator's Migrations.
+	Missing bool
+}
+
+// Migrator applies Migrations to a database.
+type Migrator struct {
+	DB *sql.DB
+
+	// Dialect is the SQL dialect of DB, "mysql" or "sqlite".
+	Dialect string
+
+	// Migrations are the Migrations to apply. They default to the embedded
+	// migrations of the Dialect.
+	Migrations []Migration
+
+	// LockTimeout is how long to wait for another run to release the
+	// migration lock. It defaults to 10 seconds.
+	LockTimeout time.Duration
+}
+
+// Up applies every pending Migration in version order and returns those it
+// applied. On MySQL, where schema changes cannot be rolled back, Migrations
+// applied before one fails stay applied; on SQLite the whole run is atomic.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn, migrations []Migration, applied map[int64]time.Time) error {
+		for _, mig := range migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+
+			if err := execScript(ctx, conn, mig.Up); err != nil {
+				return fmt.Errorf("unable to apply migration %s: %w", mig, err)
+			}
+
+			_, err := conn.ExecContext(ctx, insertVersion, mig.Version, mig.Name, time.Now().UTC().Format(time.RFC3339))
+			if err != nil {
+				return fmt.Errorf("unable to record migration %s: %w", mig, err)
+			}
+
+			done = append(done, mig)
+		}
+
+		return nil
+	})
+	if err != nil && dialects[m.Dialect].atomic {
+		done = nil
+	}
+
+	return done, err
+}
+
+// Down rolls back the last steps applied Migrations in reverse version order
+// and returns those it rolled back. Like Up, it is only atomic on SQLite.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn, migrations []Migration, applied map[int64]time.Time) error {
+		byVersion := make(map[int64]Migration, len(migrations))
+		for _, mig := range migrations {
+			byVersion[mig.Version] = mig
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, v := range versions {
+			if len(done) == steps {
+				break
+			}
+
+			mig, ok := byVersion[v]
+			if !ok {
+				return fmt.Errorf("unable to roll back version %d: migration not found", v)
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("%w: %s", ErrIrreversible, mig)
+			}
+
+			if err := execScript(ctx, conn, mig.Down); err != nil {
+				return fmt.Errorf("unable to roll back migration %s: %w", mig, err)
+			}
+
+			if _, err := conn.ExecContext(ctx, deleteVersion, mig.Version); err != nil {
+				return fmt.Errorf("unable to record rollback of migration %s: %w", mig, err)
+			}
+
+			done = append(done, mig)
+		}
+
+		return nil
+	})
+	if err != nil && dialects[m.Dialect].atomic {
+		done = nil
+	}
+
+	return done, err
+}
+
+// Status returns the Status of every Migration, and of every applied version
+// without one, in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	d, migrations, err := m.setup()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, d.createTable); err != nil {
+		return nil, fmt.Errorf("unable to create schema_migrations table: %w", err)
+	}
+
+	applied, names, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, mig := range migrations {
+		at, ok := applied[mig.Version]
+		statuses = append(statuses, Status{Migration: mig, Applied: ok, AppliedAt: at})
+		delete(applied, mig.Version)
+	}
+	for v, at := range applied {
+		statuses = append(statuses, Status{
+			Migration: Migration{Version: v, Name: names[v]},
+			Applied:   true,
+			AppliedAt: at,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// locked runs f on a dedicated connection holding the migration lock, with
+// the sorted Migrations and the versions already applied.
+func (m *Migrator) locked(ctx context.Context, f func(conn *sql.Conn, migrations []Migration, applied map[int64]time.Time) error) (err error) {
+	d, migrations, err := m.setup()
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	unlock, err := d.lock(ctx, conn, m.lockTimeout())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := unlock(err); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, d.createTable); err != nil {
+		return fmt.Errorf("unable to create schema_migrations table: %w", err)
+	}
+
+	applied, _, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return f(conn, migrations, applied)
+}
+
+// setup returns the dialect and the validated, sorted Migrations to run.
+func (m *Migrator) setup() (dialect, []Migration, error) {
+	d, ok := dialects[m.Dialect]
+	if !ok {
+		return dialect{}, nil, fmt.Errorf("migrate: unknown dialect %q", m.Dialect)
+	}
+
+	migrations := m.Migrations
+	if migrations == nil {
+		var err error
+		if migrations, err = Migrations(m.Dialect); err != nil {
+			return dialect{}, nil, err
+		}
+	}
+
+	migrations = append([]Migration(nil), migrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, mig := range migrations {
+		if mig.Version <= 0 {
+			return dialect{}, nil, fmt.Errorf("migrate: migration %s has no version", mig)
+		}
+		if i > 0 && migrations[i-1].Version == mig.Version {
+			return dialect{}, nil, fmt.Errorf("migrate: duplicate migration version %d", mig.Version)
+		}
+	}
+
+	return d, migrations, nil
+}
+
+func (m *Migrator) lockTimeout() time.Duration {
+	if m.LockTimeout <= 0 {
+		return 10 * time.Second
+	}
+
+	return m.LockTimeout
+}
+
+const (
+	selectVersions = `SELECT version, name, applied_at FROM schema_migrations`
+	insertVersion  = `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`
+	deleteVersion  = `DELETE FROM schema_migrations WHERE version = ?`
+)
+
+// appliedVersions returns when, and under which name, every version recorded
+// in the schema_migrations table was applied.
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, map[int64]string, error) {
+	rows, err := conn.QueryContext(ctx, selectVersions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read schema_migrations table: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	names := make(map[int64]string)
+	for rows.Next() {
+		var (
+			version  int64
+			name, at string
+		)
+		if err := rows.Scan(&version, &name, &at); err != nil {
+			return nil, nil, fmt.Errorf("unable to read schema_migrations table: %w", err)
+		}
+
+		// The time is informative only, so an unparsable one is left zero.
+		applied[version], _ = time.Parse(time.RFC3339, at)
+		names[version] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("unable to read schema_migrations table: %w", err)
+	}
+
+	return applied, names, nil
+}
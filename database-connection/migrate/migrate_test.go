@@ -0,0 +1,274 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-library/database-connection/migrate"
+	_ "modernc.org/sqlite"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openSQLite opens a SQLite database in a file of a temporary directory, so
+// that several connections share it.
+func openSQLite(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "users.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db, path
+}
+
+// columns returns the names of the columns of the users table.
+func columns(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT name FROM pragma_table_info('users') ORDER BY cid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return names
+}
+
+func assertMigrations(t *testing.T, got []migrate.Migration, want ...string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got migrations %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i].String() != want[i] {
+			t.Fatalf("got migrations %v, want %v", got, want)
+		}
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openSQLite(t)
+	m := migrate.Migrator{DB: db, Dialect: "sqlite"}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMigrations(t, applied, "0001_create_users", "0002_align_users_schema")
+
+	want := []string{"id", "first_name", "last_name", "email", "null_column", "created", "modified"}
+	if got := columns(t, db); len(got) != len(want) || got[0] != "id" {
+		t.Fatalf("got columns %v, want %v", got, want)
+	}
+
+	applied, err = m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMigrations(t, applied)
+
+	// Users keep their ID when rolling back to the baseline, where it is the
+	// rowid, and when migrating up again.
+	if _, err := db.Exec("INSERT INTO users (id, first_name, last_name, email) VALUES (7, 'a', 'b', 'a@b.com')"); err != nil {
+		t.Fatal(err)
+	}
+
+	rolledBack, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMigrations(t, rolledBack, "0002_align_users_schema")
+
+	var email string
+	if err := db.QueryRow("SELECT email FROM users WHERE rowid = 7").Scan(&email); err != nil {
+		t.Fatal(err)
+	}
+	if got := columns(t, db); got[0] != "first_name" {
+		t.Fatalf("got columns %v after rolling back, want no id", got)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("SELECT email FROM users WHERE id = 7").Scan(&email); err != nil {
+		t.Fatal(err)
+	}
+
+	rolledBack, err = m.Down(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMigrations(t, rolledBack, "0002_align_users_schema", "0001_create_users")
+	if got := columns(t, db); len(got) != 0 {
+		t.Fatalf("got columns %v after rolling back everything, want no users table", got)
+	}
+}
+
+func TestUpAtomic(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openSQLite(t)
+	m := migrate.Migrator{
+		DB:      db,
+		Dialect: "sqlite",
+		Migrations: []migrate.Migration{
+			{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id INTEGER PRIMARY KEY);"},
+			{Version: 2, Name: "broken", Up: "ALTER TABLE missing ADD COLUMN name text;"},
+		},
+	}
+
+	applied, err := m.Up(ctx)
+	if err == nil {
+		t.Fatal("got no error applying a broken migration")
+	}
+	assertMigrations(t, applied)
+
+	if got := columns(t, db); len(got) != 0 {
+		t.Fatalf("got columns %v, want the run rolled back", got)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			t.Fatalf("got %s applied, want the run rolled back", s.Migration)
+		}
+	}
+}
+
+func TestDownIrreversible(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openSQLite(t)
+	m := migrate.Migrator{
+		DB:         db,
+		Dialect:    "sqlite",
+		Migrations: []migrate.Migration{{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id INTEGER PRIMARY KEY);"}},
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(ctx, 1); !errors.Is(err, migrate.ErrIrreversible) {
+		t.Fatalf("got error %v, want ErrIrreversible", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openSQLite(t)
+	m := migrate.Migrator{DB: db, Dialect: "sqlite"}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// A version applied by a newer program is reported as missing.
+	missing := migrate.Migrator{
+		DB:         db,
+		Dialect:    "sqlite",
+		Migrations: []migrate.Migration{{Version: 99, Name: "newer", Up: "SELECT 1;"}},
+	}
+	if _, err := missing.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []migrate.Status{
+		{Migration: migrate.Migration{Version: 1, Name: "create_users"}, Applied: true},
+		{Migration: migrate.Migration{Version: 2, Name: "align_users_schema"}},
+		{Migration: migrate.Migration{Version: 99, Name: "newer"}, Applied: true, Missing: true},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("got %d statuses, want %d", len(statuses), len(want))
+	}
+	for i, s := range statuses {
+		w := want[i]
+		if s.Version != w.Version || s.Name != w.Name || s.Applied != w.Applied || s.Missing != w.Missing {
+			t.Errorf("got status %+v, want %+v", s, w)
+		}
+		if s.Applied && s.AppliedAt.IsZero() {
+			t.Errorf("got no applied time for %s", s.Migration)
+		}
+	}
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	db, path := openSQLite(t)
+
+	// A single connection, so that the one used by the run is checked after.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA busy_timeout = 1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	holder, err := other.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Close()
+	if _, err := holder.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := migrate.Migrator{DB: db, Dialect: "sqlite", LockTimeout: 50 * time.Millisecond}
+	if _, err := m.Up(ctx); !errors.Is(err, migrate.ErrLocked) {
+		t.Fatalf("got error %v, want ErrLocked", err)
+	}
+
+	if _, err := holder.ExecContext(ctx, "ROLLBACK"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The run leaves the connection as it found it: outside a transaction
+	// and with its own busy timeout.
+	var busyTimeout int
+	if err := db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if busyTimeout != 1234 {
+		t.Errorf("got busy timeout %d, want 1234 restored", busyTimeout)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("unable to begin a transaction after the run: %v", err)
+	}
+	tx.Rollback()
+}
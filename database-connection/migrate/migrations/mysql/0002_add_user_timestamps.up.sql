@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+  ADD COLUMN `created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  ADD COLUMN `modified` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
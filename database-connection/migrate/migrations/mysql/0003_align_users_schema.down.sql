@@ -0,0 +1,6 @@
+ALTER TABLE `users`
+  DROP INDEX `email`,
+  MODIFY COLUMN `first_name` varchar(50) DEFAULT NULL,
+  MODIFY COLUMN `last_name` varchar(50) DEFAULT NULL,
+  MODIFY COLUMN `email` varchar(50) DEFAULT NULL,
+  MODIFY COLUMN `modified` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
@@ -0,0 +1,11 @@
+-- Brings the users table to the schema of the SQLite one: names and email
+-- are required and emails are unique. Rows without an email, or sharing one,
+-- make the migration fail and must be fixed by hand first.
+UPDATE `users` SET `first_name` = '' WHERE `first_name` IS NULL;
+UPDATE `users` SET `last_name` = '' WHERE `last_name` IS NULL;
+ALTER TABLE `users`
+  MODIFY COLUMN `first_name` varchar(100) NOT NULL,
+  MODIFY COLUMN `last_name` varchar(100) NOT NULL,
+  MODIFY COLUMN `email` varchar(100) NOT NULL,
+  MODIFY COLUMN `modified` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  ADD UNIQUE KEY `email` (`email`);
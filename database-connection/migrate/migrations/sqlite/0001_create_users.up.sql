@@ -0,0 +1,12 @@
+-- Baseline matching the table previously created by the sqlite-db example, so
+-- that existing databases can be brought under migration. Users are
+-- identified by their rowid.
+CREATE TABLE IF NOT EXISTS users (
+  first_name varchar(100) NOT NULL,
+  last_name varchar(100) NOT NULL,
+  email varchar(100) NOT NULL,
+  null_column varchar(50) DEFAULT NULL,
+  created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  modified datetime NOT NULL,
+  UNIQUE(email)
+);
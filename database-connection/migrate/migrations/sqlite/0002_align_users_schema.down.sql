@@ -0,0 +1,13 @@
+CREATE TABLE users_baseline (
+  first_name varchar(100) NOT NULL,
+  last_name varchar(100) NOT NULL,
+  email varchar(100) NOT NULL,
+  null_column varchar(50) DEFAULT NULL,
+  created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  modified datetime NOT NULL,
+  UNIQUE(email)
+);
+INSERT INTO users_baseline (rowid, first_name, last_name, email, null_column, created, modified)
+  SELECT id, first_name, last_name, email, null_column, created, modified FROM users;
+DROP TABLE users;
+ALTER TABLE users_baseline RENAME TO users;
@@ -0,0 +1,18 @@
+-- Brings the users table to the schema of the MySQL one: Users get an id
+-- column, keeping their rowid, null_column holds integers and modified
+-- defaults to the current time. SQLite cannot alter columns, so the table is
+-- rebuilt.
+CREATE TABLE users_aligned (
+  id INTEGER PRIMARY KEY,
+  first_name varchar(100) NOT NULL,
+  last_name varchar(100) NOT NULL,
+  email varchar(100) NOT NULL,
+  null_column int DEFAULT NULL,
+  created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  modified datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE(email)
+);
+INSERT INTO users_aligned (id, first_name, last_name, email, null_column, created, modified)
+  SELECT rowid, first_name, last_name, email, null_column, created, modified FROM users;
+DROP TABLE users;
+ALTER TABLE users_aligned RENAME TO users;
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"go.etcd.io/bbolt"
+)
+
+// usersBucket is the bbolt Bucket Users are stored in.
+var usersBucket = []byte("users")
+
+// boltRepository is a UserRepository storing Users as JSON records of a bbolt
+// Bucket, keyed by their ID.
+type boltRepository struct {
+	db *bbolt.DB
+}
+
+// NewBolt returns a UserRepository backed by the users Bucket of a bbolt
+// database, creating the Bucket if needed. IDs are assigned from the Bucket's
+// sequence.
+func NewBolt(db *bbolt.DB) (UserRepository, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(usersBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create `%s` Bucket: %w", usersBucket, err)
+	}
+
+	return &boltRepository{db: db}, nil
+}
+
+func (r *boltRepository) Get(ctx context.Context, id int64) (User, error) {
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+
+	var u User
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(usersBucket).Get(key(id))
+		if v == nil {
+			return ErrNotFound
+		}
+
+		return json.Unmarshal(v, &u)
+	})
+	if err == ErrNotFound {
+		return User{}, err
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("unable to get user %d: %w", id, err)
+	}
+
+	return u, nil
+}
+
+func (r *boltRepository) List(ctx context.Context, opts ListOptions) ([]User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	from := opts.FromID
+	if from < 0 {
+		from = 0
+	}
+
+	var users []User
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(usersBucket).Cursor()
+
+		for k, v := c.Seek(key(from)); k != nil; k, v = c.Next() {
+			if opts.Limit > 0 && len(users) == opts.Limit {
+				break
+			}
+
+			var u User
+			if err := json.Unmarshal(v, &u); err != nil {
+				return fmt.Errorf("unable to parse User record %s: %w", v, err)
+			}
+			users = append(users, u)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *boltRepository) Create(ctx context.Context, u *User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	touch(u, true)
+
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+
+		if err := checkEmail(b, u, 0); err != nil {
+			return err
+		}
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		u.ID = int64(id)
+
+		return put(b, u)
+	})
+	if err == ErrEmailTaken {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create user: %w", err)
+	}
+
+	return nil
+}
+
+func (r *boltRepository) Update(ctx context.Context, u *User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+
+		v := b.Get(key(u.ID))
+		if v == nil {
+			return ErrNotFound
+		}
+		if err := checkEmail(b, u, u.ID); err != nil {
+			return err
+		}
+
+		var old User
+		if err := json.Unmarshal(v, &old); err != nil {
+			return err
+		}
+		u.Created = old.Created
+		touch(u, false)
+
+		return put(b, u)
+	})
+	if err == ErrNotFound || err == ErrEmailTaken {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update user %d: %w", u.ID, err)
+	}
+
+	return nil
+}
+
+func (r *boltRepository) Upsert(ctx context.Context, u *User) error {
+	if u.ID == 0 {
+		return r.Create(ctx, u)
+	}
+	if u.ID < 0 {
+		return fmt.Errorf("unable to upsert user %d: negative ID", u.ID)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if err := checkEmail(b, u, u.ID); err != nil {
+			return err
+		}
+
+		v := b.Get(key(u.ID))
+		if v == nil {
+			touch(u, true)
+
+			// Keep the sequence ahead of IDs chosen by the caller so that
+			// Create never reuses them.
+			if id := uint64(u.ID); id > b.Sequence() {
+				if err := b.SetSequence(id); err != nil {
+					return err
+				}
+			}
+
+			return put(b, u)
+		}
+
+		var old User
+		if err := json.Unmarshal(v, &old); err != nil {
+			return err
+		}
+		u.Created = old.Created
+		touch(u, false)
+
+		return put(b, u)
+	})
+	if err == ErrEmailTaken {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("unable to upsert user %d: %w", u.ID, err)
+	}
+
+	return nil
+}
+
+func (r *boltRepository) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if b.Get(key(id)) == nil {
+			return ErrNotFound
+		}
+
+		return b.Delete(key(id))
+	})
+	if err == ErrNotFound {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("unable to delete user %d: %w", id, err)
+	}
+
+	return nil
+}
+
+func (r *boltRepository) Close() error {
+	return r.db.Close()
+}
+
+// put stores u in b under its ID.
+func put(b *bbolt.Bucket, u *User) error {
+	v, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(key(u.ID), v)
+}
+
+// checkEmail returns ErrEmailTaken if a User other than the one with the given
+// ID has u's email address. bbolt has no secondary indexes, so every User is
+// read.
+func checkEmail(b *bbolt.Bucket, u *User, id int64) error {
+	return b.ForEach(func(k, v []byte) error {
+		var other User
+		if err := json.Unmarshal(v, &other); err != nil {
+			return fmt.Errorf("unable to parse User record %s: %w", v, err)
+		}
+		if other.ID != id && other.EmailAddress == u.EmailAddress {
+			return ErrEmailTaken
+		}
+
+		return nil
+	})
+}
+
+// key converts an ID to a big-endian Bucket key, so that Users are iterated in
+// ID order.
+func key(id int64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(id))
+
+	return k
+}
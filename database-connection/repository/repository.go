@@ -0,0 +1,115 @@
+// Package repository stores Users behind a single UserRepository interface
+// with MySQL, SQLite and bbolt backends, so that programs can switch between
+// them by configuration:
+//
+//	users, err := repository.Open(ctx, dbconfig.Config{Driver: "sqlite", DSN: "users.db"})
+//	if err != nil {
+//		return err
+//	}
+//	defer users.Close()
+//
+//	u := repository.User{FirstName: "first", LastName: "last", EmailAddress: "first@last.com"}
+//	err = users.Create(ctx, &u)
+//
+// Every method returns an error rather than exiting the program. Lookups of a
+// User that does not exist return ErrNotFound, and writes giving a User the
+// email address of another one return ErrEmailTaken.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	_ "github.com/go-sql-driver/mysql"
+	"go.etcd.io/bbolt"
+	"golang-library/database-connection/dbconfig"
+	_ "modernc.org/sqlite"
+	"time"
+)
+
+// ErrNotFound is returned when no User has the requested ID.
+var ErrNotFound = errors.New("repository: user not found")
+
+// ErrEmailTaken is returned when another User has the email address of the
+// written one.
+var ErrEmailTaken = errors.New("repository: email address taken")
+
+// User is a row of the users table, or a record of the users bucket.
+type User struct {
+	ID           int64         `db:"id" json:"id"`
+	FirstName    string        `db:"first_name" json:"first_name"`
+	LastName     string        `db:"last_name" json:"last_name"`
+	EmailAddress string        `db:"email" json:"email"`
+	NullValue    sql.NullInt64 `db:"null_column" json:"null_column"`
+
+	// Created and Modified are maintained by the repository.
+	Created  sql.NullTime `db:"created" json:"created"`
+	Modified sql.NullTime `db:"modified" json:"modified"`
+}
+
+// ListOptions selects the Users returned by List.
+type ListOptions struct {
+	// FromID is the lowest ID returned.
+	FromID int64
+
+	// Limit is the maximum number of Users returned. Zero means no limit.
+	Limit int
+}
+
+// UserRepository reads and writes Users.
+type UserRepository interface {
+	// Get returns the User with the given ID.
+	Get(ctx context.Context, id int64) (User, error)
+
+	// List returns the Users selected by opts, ordered by ID.
+	List(ctx context.Context, opts ListOptions) ([]User, error)
+
+	// Create stores a new User and sets its ID, ignoring the one it has.
+	Create(ctx context.Context, u *User) error
+
+	// Update replaces the User with u's ID.
+	Update(ctx context.Context, u *User) error
+
+	// Delete removes the User with the given ID.
+	Delete(ctx context.Context, id int64) error
+
+	// Upsert replaces the User with u's ID, or stores it under that ID if it
+	// does not exist. A User without an ID is created.
+	Upsert(ctx context.Context, u *User) error
+
+	// Close closes the underlying database.
+	Close() error
+}
+
+// Open opens the database described by cfg, whose Driver is "mysql",
+// "sqlite" or "bbolt", and returns a UserRepository backed by it. For bbolt
+// the DSN is the path of the database file.
+func Open(ctx context.Context, cfg dbconfig.Config) (UserRepository, error) {
+	switch cfg.Driver {
+	case "mysql", "sqlite":
+		db, err := cfg.Open(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Driver == "mysql" {
+			return NewMySQL(db), nil
+		}
+		return NewSQLite(db), nil
+	case "bbolt":
+		db, err := bbolt.Open(cfg.DSN, 0666, &bbolt.Options{Timeout: time.Duration(cfg.ConnectTimeout)})
+		if err != nil {
+			return nil, fmt.Errorf("unable to open BBolt DB file: %w", err)
+		}
+
+		r, err := NewBolt(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("repository: unknown driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,330 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"go.etcd.io/bbolt"
+	"golang-library/database-connection/migrate"
+	"golang-library/database-connection/repository"
+	_ "modernc.org/sqlite"
+	"path/filepath"
+	"testing"
+)
+
+// backends open an empty UserRepository of every backend that runs without a
+// server.
+var backends = []struct {
+	name string
+	open func(t *testing.T) repository.UserRepository
+}{
+	{
+		name: "sqlite",
+		open: func(t *testing.T) repository.UserRepository {
+			db, err := sqlx.Open("sqlite", filepath.Join(t.TempDir(), "users.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m := migrate.Migrator{DB: db.DB, Dialect: "sqlite"}
+			if _, err := m.Up(context.Background()); err != nil {
+				db.Close()
+				t.Fatal(err)
+			}
+
+			return repository.NewSQLite(db)
+		},
+	},
+	{
+		name: "bbolt",
+		open: func(t *testing.T) repository.UserRepository {
+			db, err := bbolt.Open(filepath.Join(t.TempDir(), "users.db"), 0666, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := repository.NewBolt(db)
+			if err != nil {
+				db.Close()
+				t.Fatal(err)
+			}
+
+			return r
+		},
+	},
+}
+
+// forEachBackend runs test against an empty UserRepository of every backend.
+func forEachBackend(t *testing.T, test func(t *testing.T, users repository.UserRepository)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			users := b.open(t)
+			defer users.Close()
+
+			test(t, users)
+		})
+	}
+}
+
+// create stores a User for every email, returning them with their IDs.
+func create(t *testing.T, users repository.UserRepository, emails ...string) []repository.User {
+	t.Helper()
+
+	created := make([]repository.User, len(emails))
+	for i, email := range emails {
+		created[i] = repository.User{FirstName: "first", LastName: "last", EmailAddress: email}
+		if err := users.Create(context.Background(), &created[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return created
+}
+
+func get(t *testing.T, users repository.UserRepository, id int64) repository.User {
+	t.Helper()
+
+	u, err := users.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+func assertIDs(t *testing.T, got []repository.User, want ...int64) {
+	t.Helper()
+
+	ids := make([]int64, len(got))
+	for i, u := range got {
+		ids[i] = u.ID
+	}
+	if len(ids) != len(want) {
+		t.Fatalf("got IDs %v, want %v", ids, want)
+	}
+	for i := range ids {
+		if ids[i] != want[i] {
+			t.Fatalf("got IDs %v, want %v", ids, want)
+		}
+	}
+}
+
+func TestCreateGet(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, users repository.UserRepository) {
+		created := create(t, users, "a@example.com", "b@example.com")
+		if created[0].ID <= 0 || created[1].ID <= created[0].ID {
+			t.Fatalf("got IDs %d and %d, want increasing positive IDs", created[0].ID, created[1].ID)
+		}
+
+		u := get(t, users, created[1].ID)
+		if u.ID != created[1].ID || u.FirstName != "first" || u.LastName != "last" || u.EmailAddress != "b@example.com" {
+			t.Errorf("got %+v, want %+v", u, created[1])
+		}
+		if u.NullValue.Valid {
+			t.Errorf("got null_column %v, want NULL", u.NullValue)
+		}
+		if !u.Created.Valid || !u.Modified.Valid {
+			t.Errorf("got created %v and modified %v, want both set", u.Created, u.Modified)
+		}
+
+		if _, err := users.Get(context.Background(), created[1].ID+1); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("got error %v getting a missing User, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, users repository.UserRepository) {
+		created := create(t, users, "a@example.com", "b@example.com", "c@example.com", "d@example.com")
+		ids := make([]int64, len(created))
+		for i, u := range created {
+			ids[i] = u.ID
+		}
+
+		tests := []struct {
+			name string
+			opts repository.ListOptions
+			want []int64
+		}{
+			{name: "all", want: ids},
+			{name: "from ID", opts: repository.ListOptions{FromID: ids[1]}, want: ids[1:]},
+			{name: "limit", opts: repository.ListOptions{Limit: 2}, want: ids[:2]},
+			{name: "from ID and limit", opts: repository.ListOptions{FromID: ids[1], Limit: 2}, want: ids[1:3]},
+			{name: "past the last ID", opts: repository.ListOptions{FromID: ids[3] + 1}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := users.List(context.Background(), tt.opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertIDs(t, got, tt.want...)
+			})
+		}
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, users repository.UserRepository) {
+		ctx := context.Background()
+		created := create(t, users, "a@example.com")[0]
+		before := get(t, users, created.ID)
+
+		u := repository.User{
+			ID:           created.ID,
+			FirstName:    "updated",
+			LastName:     "last",
+			EmailAddress: "updated@example.com",
+			NullValue:    sql.NullInt64{Int64: 3, Valid: true},
+		}
+		if err := users.Update(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+
+		got := get(t, users, created.ID)
+		if got.FirstName != "updated" || got.EmailAddress != "updated@example.com" || got.NullValue != u.NullValue {
+			t.Errorf("got %+v, want %+v", got, u)
+		}
+		if !got.Created.Time.Equal(before.Created.Time) {
+			t.Errorf("got created %v, want %v kept", got.Created.Time, before.Created.Time)
+		}
+		if !u.Created.Time.Equal(before.Created.Time) || !u.Modified.Valid {
+			t.Errorf("got updated User created %v and modified %v, want the stored created %v", u.Created, u.Modified, before.Created.Time)
+		}
+
+		// Updating a User with its current values is not a missing User.
+		if err := users.Update(ctx, &u); err != nil {
+			t.Errorf("got error %v updating an unchanged User", err)
+		}
+
+		missing := repository.User{ID: created.ID + 1, FirstName: "first", LastName: "last", EmailAddress: "b@example.com"}
+		if err := users.Update(ctx, &missing); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("got error %v updating a missing User, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, users repository.UserRepository) {
+		ctx := context.Background()
+
+		u := repository.User{ID: 10, FirstName: "first", LastName: "last", EmailAddress: "a@example.com"}
+		if err := users.Upsert(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+		inserted := get(t, users, 10)
+		if inserted.EmailAddress != "a@example.com" || !inserted.Created.Valid {
+			t.Errorf("got %+v, want %+v stored under its ID", inserted, u)
+		}
+
+		replacement := repository.User{ID: 10, FirstName: "replaced", LastName: "last", EmailAddress: "a@example.com"}
+		if err := users.Upsert(ctx, &replacement); err != nil {
+			t.Fatal(err)
+		}
+		replaced := get(t, users, 10)
+		if replaced.FirstName != "replaced" {
+			t.Errorf("got %+v, want %+v", replaced, replacement)
+		}
+		if !replaced.Created.Time.Equal(inserted.Created.Time) {
+			t.Errorf("got created %v, want %v kept", replaced.Created.Time, inserted.Created.Time)
+		}
+		if !replacement.Created.Time.Equal(inserted.Created.Time) || !replacement.Modified.Valid {
+			t.Errorf("got upserted User created %v and modified %v, want the stored created %v", replacement.Created, replacement.Modified, inserted.Created.Time)
+		}
+
+		// Users without an ID are created, after those stored under one.
+		created := repository.User{FirstName: "first", LastName: "last", EmailAddress: "b@example.com"}
+		if err := users.Upsert(ctx, &created); err != nil {
+			t.Fatal(err)
+		}
+		if created.ID <= 10 {
+			t.Errorf("got ID %d for a created User, want it after 10", created.ID)
+		}
+
+		all, err := users.List(ctx, repository.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertIDs(t, all, 10, created.ID)
+	})
+}
+
+func TestUpsertEmailTaken(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, users repository.UserRepository) {
+		ctx := context.Background()
+		existing := create(t, users, "a@example.com")[0]
+
+		// Upserting another ID with the email address of an existing User
+		// neither stores it nor replaces the existing User.
+		u := repository.User{ID: existing.ID + 10, FirstName: "other", LastName: "last", EmailAddress: "a@example.com"}
+		if err := users.Upsert(ctx, &u); !errors.Is(err, repository.ErrEmailTaken) {
+			t.Fatalf("got error %v, want ErrEmailTaken", err)
+		}
+
+		if got := get(t, users, existing.ID); got.FirstName != "first" {
+			t.Errorf("got %+v, want %+v unchanged", got, existing)
+		}
+		if _, err := users.Get(ctx, u.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("got error %v getting the rejected User, want ErrNotFound", err)
+		}
+
+		// An existing User taking the email address of another one is
+		// rejected too.
+		other := create(t, users, "b@example.com")[0]
+		other.EmailAddress = "a@example.com"
+		if err := users.Upsert(ctx, &other); !errors.Is(err, repository.ErrEmailTaken) {
+			t.Errorf("got error %v upserting an existing User, want ErrEmailTaken", err)
+		}
+		if err := users.Update(ctx, &other); !errors.Is(err, repository.ErrEmailTaken) {
+			t.Errorf("got error %v updating an existing User, want ErrEmailTaken", err)
+		}
+		if got := get(t, users, other.ID); got.EmailAddress != "b@example.com" {
+			t.Errorf("got email %s, want b@example.com kept", got.EmailAddress)
+		}
+
+		created := repository.User{FirstName: "first", LastName: "last", EmailAddress: "a@example.com"}
+		if err := users.Create(ctx, &created); !errors.Is(err, repository.ErrEmailTaken) {
+			t.Errorf("got error %v creating a User, want ErrEmailTaken", err)
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, users repository.UserRepository) {
+		ctx := context.Background()
+		created := create(t, users, "a@example.com", "b@example.com")
+
+		if err := users.Delete(ctx, created[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := users.Get(ctx, created[0].ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("got error %v getting a deleted User, want ErrNotFound", err)
+		}
+		if err := users.Delete(ctx, created[0].ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("got error %v deleting a missing User, want ErrNotFound", err)
+		}
+
+		all, err := users.List(ctx, repository.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertIDs(t, all, created[1].ID)
+	})
+}
+
+func TestCancelled(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, users repository.UserRepository) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		u := repository.User{FirstName: "first", LastName: "last", EmailAddress: "a@example.com"}
+		if err := users.Create(ctx, &u); !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v creating with a cancelled context, want context.Canceled", err)
+		}
+		if _, err := users.List(ctx, repository.ListOptions{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v listing with a cancelled context, want context.Canceled", err)
+		}
+	})
+}
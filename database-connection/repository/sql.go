@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"golang-library/database-connection/dbutil"
+	"modernc.org/sqlite"
+	"time"
+)
+
+// queries are the statements a sqlRepository runs, written in the dialect of
+// its database. Statements taking a User use named parameters.
+type queries struct {
+	get, list, insert, insertWithID, update, delete string
+
+	// lock selects the created time of the User with the given ID, locking
+	// its row, or the gap where it would be, until the end of the
+	// transaction where the database supports it.
+	lock string
+}
+
+// sharedQueries are the statements that MySQL and SQLite run alike, as
+// their users tables have the same schema. Only lock differs.
+var sharedQueries = queries{
+	get: `
+		SELECT
+			id,
+			first_name,
+			last_name,
+			email,
+			null_column,
+			created,
+			modified
+		FROM
+			users
+		WHERE
+			id = ?
+	`,
+	list: `
+		SELECT
+			id,
+			first_name,
+			last_name,
+			email,
+			null_column,
+			created,
+			modified
+		FROM
+			users
+		WHERE
+			id >= ?
+		ORDER BY
+			id
+	`,
+	insert: `
+		INSERT INTO
+			users (first_name, last_name, email, null_column, created, modified)
+		VALUES (:first_name, :last_name, :email, :null_column, :created, :modified)
+	`,
+	insertWithID: `
+		INSERT INTO
+			users (id, first_name, last_name, email, null_column, created, modified)
+		VALUES (:id, :first_name, :last_name, :email, :null_column, :created, :modified)
+	`,
+	update: `
+		UPDATE
+			users
+		SET
+			first_name = :first_name,
+			last_name = :last_name,
+			email = :email,
+			null_column = :null_column,
+			modified = :modified
+		WHERE
+			id = :id
+	`,
+	delete: `
+		DELETE FROM
+			users
+		WHERE
+			id = ?
+	`,
+}
+
+// mysqlQueries lock the row of an updated or upserted User, so that concurrent
+// upserts of a missing User deadlock, and are retried, rather than both
+// inserting it.
+var mysqlQueries = sharedQueries.withLock(`
+	SELECT
+		created
+	FROM
+		users
+	WHERE
+		id = ?
+	FOR UPDATE
+`)
+
+// sqliteQueries need no row lock, as SQLite locks the whole database for
+// writing.
+var sqliteQueries = sharedQueries.withLock(`
+	SELECT
+		created
+	FROM
+		users
+	WHERE
+		id = ?
+`)
+
+// withLock returns q with its lock statement set to lock.
+func (q queries) withLock(lock string) queries {
+	q.lock = lock
+	return q
+}
+
+// sqlRepository is a UserRepository storing Users in the users table of a SQL
+// database.
+type sqlRepository struct {
+	db *sqlx.DB
+	q  queries
+}
+
+// NewMySQL returns a UserRepository backed by the users table of a MySQL
+// database, as created by the migrate package. The data source name must set
+// parseTime=true for the created and modified columns to be read.
+func NewMySQL(db *sqlx.DB) UserRepository {
+	return &sqlRepository{db: db, q: mysqlQueries}
+}
+
+// NewSQLite returns a UserRepository backed by the users table of a SQLite
+// database, as created by the migrate package.
+func NewSQLite(db *sqlx.DB) UserRepository {
+	return &sqlRepository{db: db, q: sqliteQueries}
+}
+
+func (r *sqlRepository) Get(ctx context.Context, id int64) (User, error) {
+	var u User
+	err := r.db.GetContext(ctx, &u, r.q.get, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("unable to get user %d: %w", id, err)
+	}
+
+	return u, nil
+}
+
+func (r *sqlRepository) List(ctx context.Context, opts ListOptions) ([]User, error) {
+	query, args := r.q.list, []any{opts.FromID}
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	var users []User
+	if err := r.db.SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, fmt.Errorf("unable to list users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *sqlRepository) Create(ctx context.Context, u *User) error {
+	touch(u, true)
+
+	res, err := r.db.NamedExecContext(ctx, r.q.insert, u)
+	if duplicate(err) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create user: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("unable to get ID of created user: %w", err)
+	}
+	u.ID = id
+
+	return nil
+}
+
+func (r *sqlRepository) Update(ctx context.Context, u *User) error {
+	row := *u
+	touch(&row, false)
+
+	// The created time is read in the same transaction, which also tells a
+	// missing User from one that MySQL does not count as changed.
+	err := dbutil.WithTx(ctx, r.db, func(tx *dbutil.Tx) error {
+		if err := tx.GetContext(ctx, &row.Created, r.q.lock, row.ID); err != nil {
+			return err
+		}
+
+		_, err := tx.NamedExecContext(ctx, r.q.update, &row)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if duplicate(err) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update user %d: %w", u.ID, err)
+	}
+	*u = row
+
+	return nil
+}
+
+func (r *sqlRepository) Upsert(ctx context.Context, u *User) error {
+	if u.ID == 0 {
+		return r.Create(ctx, u)
+	}
+
+	// Only the ID decides whether the User is inserted or updated: an
+	// INSERT ... ON DUPLICATE KEY UPDATE would also update the User holding
+	// the same email address on MySQL.
+	row := *u
+	touch(&row, false)
+
+	err := dbutil.WithTx(ctx, r.db, func(tx *dbutil.Tx) error {
+		err := tx.GetContext(ctx, &row.Created, r.q.lock, row.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			row.Created = row.Modified
+			_, err = tx.NamedExecContext(ctx, r.q.insertWithID, &row)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NamedExecContext(ctx, r.q.update, &row)
+		return err
+	})
+	if duplicate(err) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("unable to upsert user %d: %w", u.ID, err)
+	}
+	*u = row
+
+	return nil
+}
+
+func (r *sqlRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, r.q.delete, id)
+	if err != nil {
+		return fmt.Errorf("unable to delete user %d: %w", id, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to delete user %d: %w", id, err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *sqlRepository) Close() error {
+	return r.db.Close()
+}
+
+// touch sets u's Modified time, and its Created time if created is true.
+func touch(u *User, created bool) {
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+
+	u.Modified = now
+	if created {
+		u.Created = now
+	}
+}
+
+// MySQL and SQLite error codes of unique key violations.
+const (
+	mysqlDuplicateEntry    = 1062
+	sqliteConstraintUnique = 2067
+)
+
+// duplicate reports whether err is caused by a unique key violation, which
+// only the email column can cause once IDs are known to be free.
+func duplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqliteConstraintUnique
+	}
+
+	return false
+}
@@ -9,71 +9,88 @@ package main
 // go get github.com/jmoiron/sqlx
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"golang-library/database-connection/dbconfig"
+	"golang-library/database-connection/dbutil"
+	"golang-library/database-connection/repository"
 	"log"
+	"time"
 )
 
-const (
-	dataSourceName = "root:root@tcp(localhost:3012)/golang_playground"
-)
-
-type User struct {
-	Id int `db:"id"`
-	FirstName string `db:"first_name"`
-	LastName string `db:"last_name"`
-	EmailAddress string `db:"email"`
-	NullValue sql.NullInt64 `db:"null_column"`
+// defaultConfig holds the settings not given by the file named by DB_CONFIG_FILE
+// or by the DB_* environment variables, see package dbconfig.
+var defaultConfig = dbconfig.Config{
+	Driver:          "mysql",
+	DSN:             "root:root@tcp(localhost:3012)/golang_playground?parseTime=true",
+	MaxOpenConns:    10,
+	ConnMaxLifetime: dbconfig.Duration(5 * time.Minute),
+	ConnectTimeout:  dbconfig.Duration(5 * time.Second),
 }
 
-func getDb() *sqlx.DB {
-	db, err := sqlx.Open("mysql", dataSourceName)
+func getDb(ctx context.Context) (*sqlx.DB, error) {
+	cfg, err := dbconfig.Load(defaultConfig, "DB_")
 	if err != nil {
-		log.Fatalln(err)
-	}
-
-	if err = db.Ping(); err != nil {
-		log.Fatalf("Unable to connect to database: %s", err)
+		return nil, err
 	}
+	log.Printf("Connecting to %s", cfg)
 
-	return db
+	return cfg.Open(ctx)
 }
 
 func main() {
-	db := getDb()
+	ctx := context.Background()
+
+	db, err := getDb(ctx)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer db.Close()
 
 	log.Println("Fetching users via row marshalling.")
-	log.Println(getUsersViaRowMarshal(db))
+	users, err := getUsersViaRowMarshal(db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Println(users)
+
+	log.Println("Streaming users via row marshalling.")
+	if err := streamUsersViaRowMarshal(ctx, db); err != nil {
+		log.Fatalln(err)
+	}
 
 	log.Println("Create a User via row marshalling.")
-	createUserViaRowMarshal(db, User{
-		FirstName: "row",
-		LastName: "marshal",
+	err = createUserViaRowMarshal(ctx, db, repository.User{
+		FirstName:    "row",
+		LastName:     "marshal",
 		EmailAddress: "row@marshal.com",
-		NullValue: sql.NullInt64{},
+		NullValue:    sql.NullInt64{},
 	})
+	if err != nil {
+		log.Fatalln(err)
+	}
 
 	log.Println("Fetching non-existent User via marshalling.")
 	log.Println(getNonExistentUserViaRowMarshal(db))
 
-	log.Println("Executing database operations using native sql API.")
-	users := make(chan User)
-	go getUsers(db, users)
-
-	for user := range users {
-		log.Println(user)
-	}
-
-	createUser(db)
+	// The same operations are available for every supported database through
+	// a repository.UserRepository.
+	log.Println("Executing database operations using the User repository.")
+	repo := repository.NewMySQL(db)
 
-	err := db.Close()
+	users, err = repo.List(ctx, repository.ListOptions{FromID: 35})
 	if err != nil {
-		log.Fatalf("Unable to close database connection: %s", err)
+		log.Fatalln(err)
+	}
+	for _, user := range users {
+		log.Println(user)
 	}
 }
 
-func getUsersViaRowMarshal(db *sqlx.DB) []User {
+func getUsersViaRowMarshal(db *sqlx.DB) ([]repository.User, error) {
 	query := `
 		SELECT
 			id,
@@ -87,32 +104,18 @@ func getUsersViaRowMarshal(db *sqlx.DB) []User {
 			id >= ?
 	`
 
-	var users []User
+	var users []repository.User
 	err := db.Select(&users, query, 35)
 	if err != nil {
-		log.Fatalf("Unable to execute Select query: %s. Error: %s", query, err)
+		return nil, fmt.Errorf("unable to execute Select query: %s: %w", query, err)
 	}
 
-	// Or if needed to load each User individually, use the snippet below instead
-	//var users []User
-	//rows, err := db.Queryx(query, 35)
-	//if err != nil {
-	//	log.Fatalf("Unable to execute query: %s. Error: %s", query, err)
-	//}
-	//for rows.Next() {
-	//	user := User{}
-	//	err := rows.StructScan(&user)
-	//	if err != nil {
-	//		log.Fatalln(err)
-	//	}
-	//
-	//	users = append(users, user)
-	//}
-
-	return users
+	// Or if needed to load each User individually, see streamUsersViaRowMarshal.
+
+	return users, nil
 }
 
-func getNonExistentUserViaRowMarshal(db *sqlx.DB) (User, bool) {
+func streamUsersViaRowMarshal(ctx context.Context, db *sqlx.DB) error {
 	query := `
 		SELECT
 			id,
@@ -123,21 +126,23 @@ func getNonExistentUserViaRowMarshal(db *sqlx.DB) (User, bool) {
 		FROM
 			golang_playground.users
 		WHERE
-			id = ?
+			id >= ?
 	`
 
-	var user User
-	err := db.Get(&user, query, 100)
-	if err == sql.ErrNoRows {
-		return user, false
-	} else if err != nil {
-		log.Fatalf("Unable to execute Select query: %s. Error: %s", query, err)
+	rows, err := dbutil.Query[repository.User](ctx, db, query, 35)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log.Println(rows.Value())
 	}
 
-	return user, true
+	return rows.Err()
 }
 
-func getUsers(db *sqlx.DB, users chan User) {
+func getNonExistentUserViaRowMarshal(db *sqlx.DB) (repository.User, bool, error) {
 	query := `
 		SELECT
 			id,
@@ -148,68 +153,38 @@ func getUsers(db *sqlx.DB, users chan User) {
 		FROM
 			golang_playground.users
 		WHERE
-			id >= ?
+			id = ?
 	`
-	stmt, err := db.Prepare(query)
-	if err != nil {
-		log.Fatalf("Unable to prepare query: %s. Error: %s", query, err)
-	}
 
-	rows, err := stmt.Query(35)
-	if err != nil {
-		log.Fatalf("Error executing query: %s. Error: %s", query, err)
-	}
-
-	for rows.Next() {
-		var user User
-		if err := rows.Scan(&user.Id, &user.FirstName, &user.LastName, &user.EmailAddress, &user.NullValue); err != nil {
-			log.Fatalf("Unable to scan row result: %s", err)
-		}
-
-		users <- user
+	var user repository.User
+	err := db.Get(&user, query, 100)
+	if err == sql.ErrNoRows {
+		return user, false, nil
+	} else if err != nil {
+		return user, false, fmt.Errorf("unable to execute Select query: %s: %w", query, err)
 	}
 
-	close(users)
-
-	err = rows.Close()
-	if err != nil {
-		log.Printf("Unable to close Rows after query result. Error: %s", err)
-	}
+	return user, true, nil
 }
 
-func createUserViaRowMarshal(db *sqlx.DB, u User) {
+func createUserViaRowMarshal(ctx context.Context, db *sqlx.DB, u repository.User) error {
 	insert := `
 		INSERT INTO
 			golang_playground.users (first_name, last_name, email, null_column)
 		VALUES (:first_name, :last_name, :email, :null_column)
 	`
 
-	res, err := db.NamedExec(insert, u)
-	if err != nil {
-		log.Fatalf("Unable to execute Insert query: %s. Error: %s", insert, err)
-	}
-
-	log.Println(res.LastInsertId())
-	log.Println(res.RowsAffected())
-}
-
-func createUser(db *sqlx.DB) {
-	insert := `
-		INSERT INTO
-			golang_playground.users (first_name, last_name, email, null_column)
-		VALUES (?, ?, ?, ?)
-	`
-
-	stmt, err := db.Prepare(insert)
-	if err != nil {
-		log.Fatalf("Unable to prepare query: %s. Error: %s", insert, err)
-	}
+	// The transaction is committed once the function returns nil, and is
+	// rolled back and retried if it fails on a deadlock.
+	return dbutil.WithTx(ctx, db, func(tx *dbutil.Tx) error {
+		res, err := tx.NamedExecContext(ctx, insert, u)
+		if err != nil {
+			return fmt.Errorf("unable to execute Insert query: %s: %w", insert, err)
+		}
 
-	res, err := stmt.Exec("test insert", "test insert last name", "test@test.com", sql.NullInt64{})
-	if err != nil {
-		log.Fatalf("Unable to execute query: %s. Error: %s", insert, err)
-	}
+		log.Println(res.LastInsertId())
+		log.Println(res.RowsAffected())
 
-	log.Println(res.LastInsertId())
-	log.Println(res.RowsAffected())
-}
\ No newline at end of file
+		return nil
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"golang-library/worker-pool/workerpool"
+	"log"
+	"time"
+)
+
+func main() {
+	ctx := context.Background()
+	input := make(chan int) // Update channel to required input type.
+
+	wp := workerpool.WorkerPool[[]int, struct{}]{
+		NumberOfWorkers: 4, // Update number of workers desired.
+		// Update batch size, maximum wait and target bulk work function.
+		Task: workerpool.BatchTask(ctx, input, 50, 500*time.Millisecond, bulkWorkFunction),
+	}
+
+	// Fetch the data needed for processing and send to the Input channel of the WorkPool.
+	go fetchDataForProcessing(input)
+
+	report, err := wp.Run(ctx)
+	if err != nil {
+		log.Printf("Worker pool stopped early: %s", err)
+	}
+	log.Printf("%d batches processed.", report.Processed)
+}
+
+func fetchDataForProcessing(input chan int) {
+	for rs := 0; rs < 500; rs++ {
+		input <- rs
+	}
+
+	close(input)
+}
+
+// bulkWorkFunction receives a batch of inputs, e.g. to insert them with a single statement.
+func bulkWorkFunction(ctx context.Context, batch []int) error {
+	// Simulate work being done.
+	time.Sleep(time.Second)
+	log.Printf("Processed batch of %d inputs starting at %d.", len(batch), batch[0])
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/durable"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+)
+
+func main() {
+	// Interrupt the job with Ctrl+C and run it again: only unfinished inputs are processed.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	db, err := durable.OpenDb("jobs.db")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer db.Close()
+
+	q, err := durable.Open[int](db, "fetch-data-for-processing", strconv.Itoa) // Update key function to identify each input.
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Inputs already checkpointed by a previous run keep their state.
+	if err := q.Enqueue(fetchDataForProcessing()); err != nil {
+		log.Fatalf("Unable to enqueue inputs: %s", err)
+	}
+
+	wp := workerpool.WorkerPool[int, struct{}]{
+		NumberOfWorkers: 50, // Update number of workers desired.
+		Task: workerpool.Task[int, struct{}]{
+			Execute: workFunction, // Update target work function.
+		},
+		Reporter:       workerpool.NewLogReporter(log.Default()),
+		ReportInterval: time.Second,
+	}
+
+	if _, err := durable.Run(ctx, q, &wp, false); err != nil {
+		log.Printf("Job stopped early: %s", err)
+	}
+
+	counts, err := q.Counts()
+	if err != nil {
+		log.Fatalf("Unable to count inputs: %s", err)
+	}
+	log.Printf("%d done, %d failed, %d left to resume.", counts[durable.Done], counts[durable.Failed], counts[durable.Pending]+counts[durable.Running])
+}
+
+func fetchDataForProcessing() []int {
+	var data []int
+	for rs := 0; rs < 500; rs++ {
+		data = append(data, rs)
+	}
+
+	return data
+}
+
+// workFunction signature should match the input and output types of the WorkerPool.
+func workFunction(ctx context.Context, o int) (struct{}, error) {
+	// Simulate work being done.
+	time.Sleep(time.Second)
+
+	return struct{}{}, nil
+}
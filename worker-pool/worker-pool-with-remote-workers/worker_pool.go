@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/remote"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	// Start one coordinator and any number of workers, on this box or across a LAN:
+	//   go run . -mode coordinator -addr :8080
+	//   go run . -mode worker -coordinator http://localhost:8080
+	mode := flag.String("mode", "coordinator", "coordinator or worker")
+	addr := flag.String("addr", ":8080", "address the coordinator listens on")
+	coordinator := flag.String("coordinator", "http://localhost:8080", "URL of the coordinator")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch *mode {
+	case "coordinator":
+		runCoordinator(ctx, *addr)
+	case "worker":
+		runWorker(ctx, *coordinator)
+	default:
+		log.Fatalf("Unknown mode %q", *mode)
+	}
+}
+
+func runCoordinator(ctx context.Context, addr string) {
+	c := &remote.Coordinator[int, int]{
+		Input:         fetchDataForProcessing(ctx),
+		LeaseDuration: 10 * time.Second, // Inputs of workers that stop heartbeating are handed to another worker after this.
+		MaxLeases:     3,
+	}
+
+	srv := &http.Server{Addr: addr, Handler: c}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalln(err)
+		}
+	}()
+	defer srv.Close()
+
+	report, err := c.Run(ctx)
+	if err != nil {
+		log.Printf("Job stopped early: %s", err)
+	}
+	log.Printf("%d processed, %d failed, %d unprocessed.", report.Processed, len(report.Failures), len(report.Unprocessed))
+
+	// Keep answering for a moment so polling workers learn that the job has finished.
+	time.Sleep(2 * time.Second)
+}
+
+func runWorker(ctx context.Context, coordinator string) {
+	w := remote.Worker[int, int]{
+		URL:             coordinator,
+		NumberOfWorkers: 10, // Update number of workers desired per process.
+		Task: workerpool.Task[int, int]{
+			Execute: workFunction, // Update target work function.
+			Retry:   &workerpool.RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond},
+		},
+	}
+
+	if err := w.Run(ctx); err != nil {
+		log.Printf("Worker stopped: %s", err)
+	}
+}
+
+func fetchDataForProcessing(ctx context.Context) <-chan int {
+	input := make(chan int)
+
+	go func() {
+		defer close(input)
+
+		for rs := 0; rs < 100; rs++ {
+			select {
+			case input <- rs:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return input
+}
+
+// workFunction signature should match the input and output types of the Coordinator and Worker.
+func workFunction(ctx context.Context, o int) (int, error) {
+	// Simulate work being done.
+	select {
+	case <-time.After(100 * time.Millisecond):
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	return o * 2, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/schedule"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	wp := workerpool.WorkerPool[int, struct{}]{
+		NumberOfWorkers: 50, // Update number of workers desired.
+		Task: workerpool.Task[int, struct{}]{
+			Execute: workFunction, // Update target work function.
+		},
+	}
+
+	s := schedule.Scheduler{
+		OnRun: func(st schedule.Status) {
+			if st.LastErr != nil {
+				log.Printf("Job %s failed after %s: %s", st.Name, st.LastDuration, st.LastErr)
+				return
+			}
+			log.Printf("Job %s finished in %s. Next run at %s.", st.Name, st.LastDuration, st.Next.Format(time.Kitchen))
+		},
+	}
+
+	// Cron expressions and fixed intervals are both supported.
+	if err := s.Add(schedule.PoolJob("fetch-data-for-processing", schedule.MustCron("*/5 * * * *"), &wp, fetchDataForProcessing)); err != nil {
+		log.Fatalln(err)
+	}
+	if err := s.Add(schedule.Job{Name: "heartbeat", Schedule: schedule.Every(30 * time.Second), Run: heartbeat}); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := s.Run(ctx); err != nil {
+		log.Printf("Scheduler stopped: %s", err)
+	}
+}
+
+func fetchDataForProcessing(ctx context.Context) ([]int, error) {
+	var data []int
+	for rs := 0; rs < 100; rs++ {
+		data = append(data, rs)
+	}
+
+	return data, nil
+}
+
+func heartbeat(ctx context.Context) error {
+	log.Println("Scheduler is alive.")
+
+	return nil
+}
+
+// workFunction signature should match the input and output types of the WorkerPool.
+func workFunction(ctx context.Context, o int) (struct{}, error) {
+	// Simulate work being done.
+	time.Sleep(time.Second)
+
+	return struct{}{}, nil
+}
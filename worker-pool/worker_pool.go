@@ -1,81 +1,88 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"sync"
+	"golang-library/worker-pool/workerpool"
+	"log"
+	"os"
+	"os/signal"
 	"time"
 )
 
-type WorkerPool struct {
-	NumberOfWorkers int
-	Task Task
-}
-
-type Worker struct {
-	Wg *sync.WaitGroup
-	Task Task
-}
-
-type Task struct {
-	Input chan int // Update `Input` to be required channel type.
-	Execute func(n int) // Update `Execute` to be required "Work" function signature.
-}
-
-func (wp *WorkerPool) Run (done chan bool) {
-	var wg sync.WaitGroup
-	wg.Add(wp.NumberOfWorkers)
-
-	for i := 0; i < wp.NumberOfWorkers; i++ {
-		w := Worker{
-			Wg: &wg,
-			Task: wp.Task,
-		}
-
-		go w.Work()
-	}
-
-	wg.Wait()
-	done <- true
-}
-
-func (w *Worker) Work() {
-	defer w.Wg.Done()
-
-	for i := range w.Task.Input {
-		w.Task.Execute(i)
-	}
-}
-
 func main() {
-	input := make(chan int) // Update channel to required channel type.
+	// Cancel the pool on Ctrl+C so in-flight work can finish gracefully.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	input := make(chan int) // Update channel to required input type.
+	results := make(chan workerpool.Result[int, int])
 
 	done := make(chan bool)
-	wp := WorkerPool{
+	wp := workerpool.WorkerPool[int, int]{
 		NumberOfWorkers: 50, // Update number of workers desired.
-		Task: Task{
-			Input: input,
+		Task: workerpool.Task[int, int]{
+			Input:   input,
 			Execute: workFunction, // Update target work function.
 		},
+		Results:      results,
+		DrainTimeout: 5 * time.Second,
+		ErrorPolicy:  workerpool.StopAfterErrors(10), // Update to the desired failure handling.
+		Retry: &workerpool.RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   100 * time.Millisecond,
+			MaxDelay:    time.Second,
+			Jitter:      0.5,
+		},
+		RateLimit: workerpool.NewRateLimiter(100, 10), // Update to the downstream quota.
+
+		// Stop calling a downstream that is down until it recovers.
+		Breaker: &workerpool.CircuitBreaker{FailureRate: 0.5, Cooldown: 30 * time.Second},
 	}
-	go wp.Run(done)
+	go func() {
+		report, err := wp.Run(ctx)
+		for _, f := range report.Failures {
+			log.Printf("Failed to process input %v: %s", f.Input, f.Err)
+		}
+		if len(report.Unprocessed) > 0 {
+			log.Printf("Worker pool stopped early: %s. %d inputs were not processed: %v", err, len(report.Unprocessed), report.Unprocessed)
+		}
+
+		done <- true
+	}()
 
 	// Fetch the data needed for processing and send to the Input channel of the WorkPool.
-	fetchDataForProcessing(input)
+	go fetchDataForProcessing(ctx, input)
+
+	for r := range results {
+		if r.Err == nil {
+			fmt.Println(r.Output)
+		}
+	}
 
 	<-done
 }
 
-func fetchDataForProcessing(input chan int) {
+func fetchDataForProcessing(ctx context.Context, input chan int) {
+	defer close(input)
+
 	for rs := 0; rs < 200; rs++ {
-		input <- rs
+		select {
+		case input <- rs:
+		case <-ctx.Done():
+			return
+		}
 	}
-
-	close(input)
 }
 
-// workFunction signature should match the individual element in the `input` channel type.
-func workFunction(o int) {
+// workFunction signature should match the input and output types of the WorkerPool.
+func workFunction(ctx context.Context, o int) (int, error) {
 	// Simulate work being done.
-	time.Sleep(time.Second)
-	fmt.Println(o)
+	select {
+	case <-time.After(time.Second):
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	return o, nil
 }
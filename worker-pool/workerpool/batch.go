@@ -0,0 +1,147 @@
+package workerpool
+
+import (
+	"context"
+	"time"
+)
+
+// Batch groups the values received from in into slices of up to size values.
+// A partial batch is emitted once maxWait has passed since its first value
+// was received, or when in is closed. A maxWait of zero only emits full
+// batches, plus the final partial one.
+//
+// The returned channel is closed once in is closed and the last batch has
+// been received, or when ctx is done, in which case the values of the batch
+// being filled are dropped. BatchTask hands them back to the pool instead.
+func Batch[In any](ctx context.Context, in <-chan In, size int, maxWait time.Duration) <-chan []In {
+	out := make(chan []In)
+
+	go func() {
+		defer close(out)
+		batch(ctx, in, size, maxWait, RealClock, out, nil)
+	}()
+
+	return out
+}
+
+// batch runs the loop of Batch, timing partial batches on clock, until in is
+// closed, ctx is done or stop is closed. It returns the values it received
+// but did not send.
+func batch[In any](ctx context.Context, in <-chan In, size int, maxWait time.Duration, clock Clock, out chan<- []In, stop <-chan struct{}) []In {
+	if size < 1 {
+		size = 1
+	}
+
+	var (
+		values  []In
+		timer   Timer
+		timeout <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	flush := func() bool {
+		if timer != nil {
+			timer.Stop()
+			timer, timeout = nil, nil
+		}
+		if len(values) == 0 {
+			return true
+		}
+
+		select {
+		case out <- values:
+			values = nil
+			return true
+		case <-ctx.Done():
+			return false
+		case <-stop:
+			return false
+		}
+	}
+
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				flush()
+				return values
+			}
+
+			values = append(values, v)
+			if len(values) == 1 && maxWait > 0 {
+				timer = clock.NewTimer(maxWait)
+				timeout = timer.C()
+			}
+			if len(values) >= size && !flush() {
+				return values
+			}
+		case <-timeout:
+			if !flush() {
+				return values
+			}
+		case <-ctx.Done():
+			return values
+		case <-stop:
+			return values
+		}
+	}
+}
+
+// batchSource feeds the Input of a BatchTask with Batch for every Run.
+type batchSource[In any] struct {
+	ctx     context.Context
+	in      <-chan In
+	size    int
+	maxWait time.Duration
+}
+
+func (s batchSource[In]) open(clock Clock) (<-chan []In, func() [][]In) {
+	out := make(chan []In)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	var held []In
+	go func() {
+		defer close(done)
+		defer close(out)
+		held = batch(s.ctx, s.in, s.size, s.maxWait, clock, out, stop)
+	}()
+
+	return out, func() [][]In {
+		close(stop)
+		<-done
+
+		if len(held) == 0 {
+			return nil
+		}
+		return [][]In{held}
+	}
+}
+
+// BatchTask returns a Task that groups the values received from input with
+// Batch and calls execute once per batch, for bulk inserts, bulk HTTP posts
+// and the like:
+//
+//	wp := workerpool.WorkerPool[[]User, struct{}]{
+//		NumberOfWorkers: 4,
+//		Task:            workerpool.BatchTask(ctx, users, 100, time.Second, createUsers),
+//	}
+//
+// Failures, retries and Results of the pool then apply to whole batches.
+//
+// The Task has no Input: every Run of the pool batches input afresh, timing
+// partial batches on the pool's Clock, until ctx is done or the Run stops.
+// The partial batch being filled at that point is reported as a single
+// Unprocessed Input.
+func BatchTask[In any](ctx context.Context, input <-chan In, size int, maxWait time.Duration, execute func(ctx context.Context, batch []In) error) Task[[]In, struct{}] {
+	return Task[[]In, struct{}]{
+		Execute: func(ctx context.Context, batch []In) (struct{}, error) {
+			return struct{}{}, execute(ctx, batch)
+		},
+		source: batchSource[In]{ctx: ctx, in: input, size: size, maxWait: maxWait},
+	}
+}
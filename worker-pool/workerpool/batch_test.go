@@ -0,0 +1,175 @@
+package workerpool_test
+
+import (
+	"context"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []int
+		size  int
+		want  []int
+	}{
+		{name: "full batches", input: workerpooltest.Sequence(9), size: 3, want: []int{3, 3, 3}},
+		{name: "final partial batch", input: workerpooltest.Sequence(10), size: 4, want: []int{4, 4, 2}},
+		{name: "empty input", input: nil, size: 4, want: nil},
+		{name: "size below one", input: workerpooltest.Sequence(2), size: 0, want: []int{1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sizes, values []int
+			for batch := range workerpool.Batch(context.Background(), workerpooltest.Feed(tt.input...), tt.size, 0) {
+				sizes = append(sizes, len(batch))
+				values = append(values, batch...)
+			}
+
+			workerpooltest.AssertOrder(t, sizes, tt.want)
+			workerpooltest.AssertOrder(t, values, tt.input)
+		})
+	}
+}
+
+func TestBatchMaxWait(t *testing.T) {
+	in := make(chan int)
+	defer close(in)
+
+	batches := workerpool.Batch(context.Background(), in, 10, time.Millisecond)
+
+	in <- 1
+	in <- 2
+
+	workerpooltest.AssertOrder(t, <-batches, []int{1, 2})
+}
+
+func TestBatchTask(t *testing.T) {
+	// The script is keyed by batch size.
+	script := workerpooltest.NewScript[int](workerpooltest.Succeed(struct{}{}))
+	task := workerpool.BatchTask(context.Background(), workerpooltest.Feed(workerpooltest.Sequence(25)...), 10, 0,
+		func(ctx context.Context, batch []int) error {
+			_, err := script.Execute(ctx, len(batch))
+			return err
+		})
+
+	wp := workerpool.WorkerPool[[]int, struct{}]{NumberOfWorkers: 2, Task: task}
+	rep, err := wp.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Processed != 3 {
+		t.Errorf("got %d batches, want 3", rep.Processed)
+	}
+	workerpooltest.AssertSameElements(t, script.Started(), []int{10, 10, 5})
+}
+
+func TestBatchTaskMaxWait(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	in := make(chan int)
+
+	sizes := make(chan int, 2)
+	wp := workerpool.WorkerPool[[]int, struct{}]{
+		NumberOfWorkers: 1,
+		Task: workerpool.BatchTask(context.Background(), in, 10, time.Second,
+			func(ctx context.Context, batch []int) error {
+				sizes <- len(batch)
+				return nil
+			}),
+		Clock: clock,
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := wp.Run(context.Background())
+		done <- err
+	}()
+
+	in <- 1
+	in <- 2
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if got := <-sizes; got != 2 {
+		t.Errorf("got batch of %d after maxWait, want 2", got)
+	}
+
+	in <- 3
+	close(in)
+	if got := <-sizes; got != 1 {
+		t.Errorf("got final batch of %d, want 1", got)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchTaskUnprocessed(t *testing.T) {
+	// The first batch keeps the only Worker busy past the DrainTimeout, so the
+	// second one cannot be handed off and the Batch either holds a partial
+	// third batch or waits to send a full one when ctx is cancelled.
+	tests := []struct {
+		name  string
+		input []int
+		want  []int
+	}{
+		{name: "partial batch", input: workerpooltest.Sequence(5), want: workerpooltest.Sequence(5)},
+		{name: "full batch", input: workerpooltest.Sequence(6), want: workerpooltest.Sequence(6)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			in := make(chan int)
+			defer close(in)
+
+			clock := workerpooltest.NewClock(time.Now())
+			started := make(chan struct{})
+			release := make(chan struct{})
+			defer close(release)
+
+			wp := workerpool.WorkerPool[[]int, struct{}]{
+				NumberOfWorkers: 1,
+				Task: workerpool.BatchTask(ctx, in, 2, 0, func(ctx context.Context, batch []int) error {
+					close(started)
+					<-release
+					return nil
+				}),
+				DrainTimeout: time.Minute,
+				Clock:        clock,
+			}
+
+			done := make(chan workerpool.Report[[]int])
+			go func() {
+				rep, _ := wp.Run(ctx)
+				done <- rep
+			}()
+
+			for _, v := range tt.input {
+				in <- v
+				if v == 1 {
+					<-started
+				}
+			}
+			cancel()
+			clock.BlockUntil(1)
+			clock.Advance(time.Minute)
+
+			rep := <-done
+			if rep.Processed != 0 {
+				t.Errorf("got %d batches processed, want 0", rep.Processed)
+			}
+
+			var unprocessed []int
+			for _, batch := range rep.Unprocessed {
+				unprocessed = append(unprocessed, batch...)
+			}
+			workerpooltest.AssertSameElements(t, unprocessed, tt.want)
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is recorded for an execution short-circuited by an open
+// CircuitBreaker. It is retried like any other error.
+var ErrCircuitOpen = errors.New("workerpool: circuit breaker is open")
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every execution through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen short-circuits every execution with ErrCircuitOpen.
+	BreakerOpen
+	// BreakerHalfOpen lets a few trial executions through to find out
+	// whether the downstream has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops a pool from hammering a downstream that is down. It
+// tracks the outcome of the most recent executions and opens once their
+// failure rate reaches FailureRate, short-circuiting executions with
+// ErrCircuitOpen instead of calling Execute. After Cooldown it lets
+// HalfOpenRequests trial executions through: if they all succeed it closes
+// again, otherwise it stays open for another Cooldown.
+//
+// The zero value is ready to use with the defaults below. A CircuitBreaker
+// can be shared by several pools calling the same downstream.
+type CircuitBreaker struct {
+	// FailureRate is the share of failed executions, between 0 and 1, that
+	// opens the breaker. It defaults to 0.5.
+	FailureRate float64
+
+	// WindowSize is the number of most recent executions the failure rate is
+	// computed over, and MinRequests the number of them needed before the
+	// breaker can open. They default to 20 and 10.
+	WindowSize  int
+	MinRequests int
+
+	// Cooldown is how long the breaker stays open before letting trial
+	// executions through. It defaults to 30 seconds.
+	Cooldown time.Duration
+
+	// HalfOpenRequests is the number of trial executions that must succeed to
+	// close the breaker. It defaults to one.
+	HalfOpenRequests int
+
+	// IsFailure decides which errors count as failures. By default every
+	// error does, except context cancellation caused by the pool stopping.
+	IsFailure func(err error) bool
+
+	mu         sync.Mutex
+	state      BreakerState
+	generation uint64
+	outcomes   []bool
+	next       int
+	openedAt   time.Time
+	trials     int
+	successes  int
+}
+
+// allow reports whether an execution may go ahead at now, returning the
+// generation to pass to record, or ErrCircuitOpen.
+func (b *CircuitBreaker) allow(now time.Time) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+
+	switch b.state {
+	case BreakerOpen:
+		return 0, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.trials >= b.halfOpenRequests() {
+			return 0, ErrCircuitOpen
+		}
+		b.trials++
+	}
+
+	return b.generation, nil
+}
+
+// record counts the outcome of an execution allowed in the given generation.
+// Outcomes of executions that started before the breaker last changed state
+// are ignored, and so are cancelled trials, which only free their slot: they
+// tell nothing about whether the downstream has recovered.
+func (b *CircuitBreaker) record(generation uint64, err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	failed := b.isFailure(err)
+
+	switch b.state {
+	case BreakerHalfOpen:
+		b.trials--
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		if failed {
+			b.transition(BreakerOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.halfOpenRequests() {
+			b.transition(BreakerClosed, now)
+		}
+	case BreakerClosed:
+		size := b.windowSize()
+		if len(b.outcomes) < size {
+			b.outcomes = append(b.outcomes, failed)
+		} else {
+			b.outcomes[b.next] = failed
+			b.next = (b.next + 1) % size
+		}
+
+		if b.tripped() {
+			b.transition(BreakerOpen, now)
+		}
+	}
+}
+
+// stateAt returns the state of the breaker at now.
+func (b *CircuitBreaker) stateAt(now time.Time) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+
+	return b.state
+}
+
+// advance moves an open breaker to half-open once its cooldown has passed.
+// The caller must hold b.mu.
+func (b *CircuitBreaker) advance(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.cooldown() {
+		b.transition(BreakerHalfOpen, now)
+	}
+}
+
+// transition moves the breaker to state, starting a new generation. The
+// caller must hold b.mu.
+func (b *CircuitBreaker) transition(state BreakerState, now time.Time) {
+	b.state = state
+	b.generation++
+	b.trials = 0
+	b.successes = 0
+
+	switch state {
+	case BreakerOpen:
+		b.openedAt = now
+	case BreakerClosed:
+		b.outcomes = b.outcomes[:0]
+		b.next = 0
+	}
+}
+
+// tripped reports whether the recent failure rate should open the breaker.
+// The caller must hold b.mu.
+func (b *CircuitBreaker) tripped() bool {
+	if len(b.outcomes) < b.minRequests() {
+		return false
+	}
+
+	failures := 0
+	for _, failed := range b.outcomes {
+		if failed {
+			failures++
+		}
+	}
+
+	return float64(failures) >= b.failureRate()*float64(len(b.outcomes))
+}
+
+func (b *CircuitBreaker) isFailure(err error) bool {
+	if b.IsFailure != nil {
+		return b.IsFailure(err)
+	}
+
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+func (b *CircuitBreaker) failureRate() float64 {
+	if b.FailureRate <= 0 || b.FailureRate > 1 {
+		return 0.5
+	}
+
+	return b.FailureRate
+}
+
+func (b *CircuitBreaker) windowSize() int {
+	if b.WindowSize <= 0 {
+		return 20
+	}
+
+	return b.WindowSize
+}
+
+func (b *CircuitBreaker) minRequests() int {
+	n := b.MinRequests
+	if n <= 0 {
+		n = 10
+	}
+	if size := b.windowSize(); n > size {
+		n = size
+	}
+
+	return n
+}
+
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown <= 0 {
+		return 30 * time.Second
+	}
+
+	return b.Cooldown
+}
+
+func (b *CircuitBreaker) halfOpenRequests() int {
+	if b.HalfOpenRequests <= 0 {
+		return 1
+	}
+
+	return b.HalfOpenRequests
+}
+
+// guardedAttempt executes the Task against in once through the pool's
+// CircuitBreaker, if it has one.
+func (r *run[In, Out]) guardedAttempt(ctx context.Context, in In) (Out, error) {
+	b := r.pool.Breaker
+	if b == nil {
+		return r.attempt(ctx, in)
+	}
+
+	clock := r.pool.clock()
+
+	generation, err := b.allow(clock.Now())
+	if err != nil {
+		r.shortCircuited.Add(1)
+		var zero Out
+		return zero, err
+	}
+
+	out, err := r.attempt(ctx, in)
+	b.record(generation, err, clock.Now())
+
+	return out, err
+}
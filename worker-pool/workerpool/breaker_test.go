@@ -0,0 +1,152 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	script := workerpooltest.NewScript[int](workerpooltest.Fail[int](errBoom))
+
+	wp := workerpool.WorkerPool[int, int]{
+		NumberOfWorkers: 1,
+		Task: workerpool.Task[int, int]{
+			Input:   workerpooltest.Feed(workerpooltest.Sequence(10)...),
+			Execute: script.Execute,
+		},
+		Breaker: &workerpool.CircuitBreaker{WindowSize: 4, MinRequests: 4, Cooldown: time.Hour},
+		Clock:   clock,
+	}
+
+	rep, err := wp.Run(context.Background())
+	if !errors.Is(err, workerpool.ErrCircuitOpen) {
+		t.Fatalf("got error %v, want ErrCircuitOpen", err)
+	}
+	if len(rep.Failures) != 10 {
+		t.Errorf("got %d failures, want 10", len(rep.Failures))
+	}
+	if n := len(script.Started()); n != 4 {
+		t.Errorf("got %d executions, want 4", n)
+	}
+
+	s := wp.Stats()
+	if s.Breaker != workerpool.BreakerOpen || s.ShortCircuited != 6 {
+		t.Errorf("got breaker %s with %d short-circuited, want open with 6", s.Breaker, s.ShortCircuited)
+	}
+}
+
+func TestCircuitBreakerRecovers(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		wantCalls    int
+		wantShort    int
+		wantAttempts int
+	}{
+		{name: "first trial succeeds", failures: 4, wantCalls: 5, wantShort: 2, wantAttempts: 7},
+		{name: "first trial fails", failures: 5, wantCalls: 6, wantShort: 4, wantAttempts: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := workerpooltest.NewClock(time.Now())
+			defer clock.AutoAdvance()()
+
+			var (
+				mu    sync.Mutex
+				calls int
+			)
+			execute := func(ctx context.Context, in int) (int, error) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				calls++
+				if calls <= tt.failures {
+					return 0, errBoom
+				}
+				return in, nil
+			}
+
+			// Attempts are a minute apart, and the breaker opens after four
+			// failures and lets a trial through after two and a half minutes.
+			wp := workerpool.WorkerPool[int, int]{
+				NumberOfWorkers: 1,
+				Task:            workerpool.Task[int, int]{Input: workerpooltest.Feed(1), Execute: execute},
+				Retry:           &workerpool.RetryPolicy{MaxAttempts: 10, BaseDelay: time.Minute, MaxDelay: time.Minute},
+				Breaker:         &workerpool.CircuitBreaker{WindowSize: 4, MinRequests: 4, Cooldown: 150 * time.Second},
+				Results:         make(chan workerpool.Result[int, int], 1),
+				Clock:           clock,
+			}
+
+			if _, err := wp.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			res := <-wp.Results
+			if calls != tt.wantCalls || res.Attempts != tt.wantAttempts {
+				t.Errorf("got %d calls in %d attempts, want %d calls in %d attempts", calls, res.Attempts, tt.wantCalls, tt.wantAttempts)
+			}
+
+			s := wp.Stats()
+			if s.Breaker != workerpool.BreakerClosed || s.ShortCircuited != tt.wantShort {
+				t.Errorf("got breaker %s with %d short-circuited, want closed with %d", s.Breaker, s.ShortCircuited, tt.wantShort)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerCancelledTrial(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	breaker := &workerpool.CircuitBreaker{WindowSize: 2, MinRequests: 2, Cooldown: time.Minute, HalfOpenRequests: 2}
+
+	failing := workerpool.WorkerPool[int, int]{
+		NumberOfWorkers: 1,
+		Task: workerpool.Task[int, int]{
+			Input:   workerpooltest.Feed(1, 2),
+			Execute: workerpooltest.NewScript[int](workerpooltest.Fail[int](errBoom)).Execute,
+		},
+		Breaker: breaker,
+		Clock:   clock,
+	}
+	failing.Run(context.Background())
+	if s := failing.Stats(); s.Breaker != workerpool.BreakerOpen {
+		t.Fatalf("got breaker %s, want open", s.Breaker)
+	}
+
+	clock.Advance(time.Minute)
+
+	// The first trial is cancelled, which frees its slot without counting as
+	// a success, so the breaker needs two more before closing.
+	var calls int
+	execute := func(ctx context.Context, in int) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, context.Canceled
+		}
+		return in, nil
+	}
+	trials := workerpool.WorkerPool[int, int]{
+		NumberOfWorkers: 1,
+		Task:            workerpool.Task[int, int]{Input: workerpooltest.Feed(3, 4), Execute: execute},
+		Breaker:         breaker,
+		Clock:           clock,
+	}
+	trials.Run(context.Background())
+	if s := trials.Stats(); s.Breaker != workerpool.BreakerHalfOpen || s.ShortCircuited != 0 {
+		t.Fatalf("got breaker %s with %d short-circuited, want half-open with none", s.Breaker, s.ShortCircuited)
+	}
+
+	trials.Task.Input = workerpooltest.Feed(5)
+	if _, err := trials.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := trials.Stats(); s.Breaker != workerpool.BreakerClosed {
+		t.Errorf("got breaker %s, want closed", s.Breaker)
+	}
+}
@@ -0,0 +1,107 @@
+package workerpool
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and creates timers for a pool: retry backoff, Task
+// timeouts, rate limiting, the DrainTimeout, latency measurements, the
+// Reporter and Autoscaler intervals and the maxWait of a BatchTask all go
+// through it. A PriorityQueue measures aging on the Clock passed to
+// NewPriorityQueue. Tests can use a fake Clock, such as the one in package
+// workerpooltest, to exercise them without waiting.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-shot timer created by a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// RealClock is the Clock used when a pool does not set one. It is backed by
+// the time package.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+// clock returns the pool's Clock, or RealClock if it has none.
+func (wp *WorkerPool[In, Out]) clock() Clock {
+	if wp.Clock == nil {
+		return RealClock
+	}
+
+	return wp.Clock
+}
+
+// sleep waits for d on c, returning false if ctx is done first.
+func sleep(ctx context.Context, c Clock, d time.Duration) bool {
+	t := c.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// withTimeout is context.WithTimeout measured on c.
+func withTimeout(ctx context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if c == RealClock {
+		return context.WithTimeout(ctx, d)
+	}
+
+	cancelCtx, cancel := context.WithCancelCause(ctx)
+	t := c.NewTimer(d)
+
+	go func() {
+		select {
+		case <-t.C():
+			cancel(context.DeadlineExceeded)
+		case <-cancelCtx.Done():
+			t.Stop()
+		}
+	}()
+
+	return deadlineCtx{cancelCtx}, func() { cancel(nil) }
+}
+
+// deadlineCtx is a context cancelled by a timer of a Clock other than
+// RealClock. It reports context.DeadlineExceeded once the timer fired.
+type deadlineCtx struct {
+	context.Context
+}
+
+func (c deadlineCtx) Err() error {
+	err := c.Context.Err()
+	if err != nil && context.Cause(c.Context) == context.DeadlineExceeded {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
@@ -0,0 +1,354 @@
+// Package durable checkpoints the state of every Input of a workerpool job in
+// a BBolt DB so a job interrupted by a crash can resume with only the Inputs
+// that did not finish.
+//
+// Dependencies:
+// BBolt DB: go get go.etcd.io/bbolt/...
+//
+// Usage:
+//
+//	db, err := durable.OpenDb("jobs.db")
+//	q, err := durable.Open[int](db, "fetch-data", strconv.Itoa)
+//
+//	// Enqueue is idempotent, so the full batch can be enqueued on every start.
+//	err = q.Enqueue(fetchDataForProcessing())
+//
+//	wp := workerpool.WorkerPool[int, struct{}]{
+//		NumberOfWorkers: 50,
+//		Task:            workerpool.Task[int, struct{}]{Execute: workFunction},
+//	}
+//	report, err := durable.Run(ctx, q, &wp, false)
+package durable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"golang-library/worker-pool/workerpool"
+	"sort"
+	"time"
+)
+
+// State is the checkpointed state of a single Input.
+type State string
+
+const (
+	Pending State = "pending"
+	Running State = "running"
+	Done    State = "done"
+	Failed  State = "failed"
+)
+
+// jobsBucket is the top-level Bucket holding one nested Bucket per job.
+var jobsBucket = []byte("jobs")
+
+// record is the value stored for each Input of a job.
+type record[In any] struct {
+	Seq      uint64    `json:"seq"`
+	Input    In        `json:"input"`
+	State    State     `json:"state"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error,omitempty"`
+	Updated  time.Time `json:"updated"`
+}
+
+// Queue tracks the Inputs of a single named job.
+type Queue[In any] struct {
+	db  *bbolt.DB
+	job []byte
+	key func(in In) string
+}
+
+// OpenDb opens the BBolt DB at the provided path and initializes the Buckets
+// used by Queues.
+func OpenDb(dbPath string) (*bbolt.DB, error) {
+	db, err := bbolt.Open(dbPath, 0666, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open BBolt DB file: %w", err)
+	}
+
+	if err := setupDb(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// setupDb initializes all Buckets in the provided BBolt DB instance.
+func setupDb(db *bbolt.DB) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(jobsBucket); err != nil {
+			return fmt.Errorf("unable to create `%s` Bucket: %w", jobsBucket, err)
+		}
+
+		return nil
+	})
+}
+
+// Open returns the Queue for the named job, creating its Bucket if needed.
+// key must return a unique, stable identifier for each Input; it is used to
+// recognize Inputs that were already enqueued.
+func Open[In any](db *bbolt.DB, job string, key func(in In) string) (*Queue[In], error) {
+	if err := setupDb(db); err != nil {
+		return nil, err
+	}
+
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.Bucket(jobsBucket).CreateBucketIfNotExists([]byte(job))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Bucket for job `%s`: %w", job, err)
+	}
+
+	return &Queue[In]{db: db, job: []byte(job), key: key}, nil
+}
+
+// bucket returns the job's Bucket within tx.
+func (q *Queue[In]) bucket(tx *bbolt.Tx) *bbolt.Bucket {
+	return tx.Bucket(jobsBucket).Bucket(q.job)
+}
+
+// Enqueue adds the provided Inputs as Pending. Inputs that are already part
+// of the job keep their current state.
+func (q *Queue[In]) Enqueue(inputs []In) error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := q.bucket(tx)
+
+		for _, in := range inputs {
+			k := []byte(q.key(in))
+			if b.Get(k) != nil {
+				continue
+			}
+
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			if err := put(b, k, record[In]{Seq: seq, Input: in, State: Pending, Updated: time.Now()}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Resume returns, in the order they were enqueued, the Inputs that still need
+// to be executed: Pending ones, and Running ones whose previous run was
+// interrupted. Failed Inputs are included if retryFailed is true.
+func (q *Queue[In]) Resume(retryFailed bool) ([]In, error) {
+	var records []record[In]
+
+	err := q.db.View(func(tx *bbolt.Tx) error {
+		return q.bucket(tx).ForEach(func(k, v []byte) error {
+			var rec record[In]
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("unable to parse record %s: %w", k, err)
+			}
+
+			if rec.State == Pending || rec.State == Running || (retryFailed && rec.State == Failed) {
+				records = append(records, rec)
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load unfinished inputs of job `%s`: %w", q.job, err)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Seq < records[j].Seq
+	})
+
+	inputs := make([]In, len(records))
+	for i, rec := range records {
+		inputs[i] = rec.Input
+	}
+
+	return inputs, nil
+}
+
+// Counts returns the number of Inputs of the job in each State.
+func (q *Queue[In]) Counts() (map[State]int, error) {
+	counts := make(map[State]int)
+
+	err := q.db.View(func(tx *bbolt.Tx) error {
+		return q.bucket(tx).ForEach(func(k, v []byte) error {
+			var rec struct {
+				State State `json:"state"`
+			}
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("unable to parse record %s: %w", k, err)
+			}
+			counts[rec.State]++
+
+			return nil
+		})
+	})
+
+	return counts, err
+}
+
+// Reset deletes every checkpoint of the job, so the next Enqueue starts it
+// from scratch.
+func (q *Queue[In]) Reset() error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(jobsBucket).DeleteBucket(q.job); err != nil {
+			return err
+		}
+		_, err := tx.Bucket(jobsBucket).CreateBucket(q.job)
+
+		return err
+	})
+}
+
+// start checkpoints in as Running and returns the number of the attempt it
+// starts, which identifies the attempt to finish.
+func (q *Queue[In]) start(in In) (int, error) {
+	var attempt int
+
+	err := q.db.Update(func(tx *bbolt.Tx) error {
+		b := q.bucket(tx)
+		k := []byte(q.key(in))
+
+		rec := record[In]{Input: in}
+		if v := b.Get(k); v != nil {
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("unable to parse record %s: %w", k, err)
+			}
+		} else {
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			rec.Seq = seq
+		}
+
+		rec.State = Running
+		rec.Updated = time.Now()
+		rec.Error = ""
+		rec.Attempts++
+		attempt = rec.Attempts
+
+		return put(b, k, rec)
+	})
+
+	return attempt, err
+}
+
+// finish moves in from Running to the provided state, recording execErr for
+// Failed Inputs. It leaves the checkpoint alone unless in is still Running the
+// given attempt, so an abandoned attempt that returns late cannot overwrite
+// the outcome of a later one.
+func (q *Queue[In]) finish(in In, attempt int, state State, execErr error) error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := q.bucket(tx)
+		k := []byte(q.key(in))
+
+		v := b.Get(k)
+		if v == nil {
+			return nil
+		}
+
+		var rec record[In]
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return fmt.Errorf("unable to parse record %s: %w", k, err)
+		}
+		if rec.State != Running || rec.Attempts != attempt {
+			return nil
+		}
+
+		rec.State = state
+		rec.Updated = time.Now()
+		rec.Error = ""
+		if execErr != nil {
+			rec.Error = execErr.Error()
+		}
+
+		return put(b, k, rec)
+	})
+}
+
+func put[In any](b *bbolt.Bucket, k []byte, rec record[In]) error {
+	v, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(k, v)
+}
+
+// Wrap returns an Execute function that checkpoints each Input as Running
+// before calling execute, and as Done or Failed once it returns. An Input
+// whose checkpoint cannot be written fails without being executed.
+//
+// An Input whose context was cancelled, because the DrainTimeout expired or
+// the pool stopped, stays Running so the next Run executes it again. An
+// attempt that timed out is checkpointed as Failed, even if it later returns
+// without an error, unless a later attempt was started in the meantime.
+func Wrap[In, Out any](q *Queue[In], execute func(ctx context.Context, in In) (Out, error)) func(ctx context.Context, in In) (Out, error) {
+	return func(ctx context.Context, in In) (Out, error) {
+		attempt, err := q.start(in)
+		if err != nil {
+			var zero Out
+			return zero, fmt.Errorf("unable to checkpoint input: %w", err)
+		}
+
+		out, err := execute(ctx, in)
+
+		ctxErr := ctx.Err()
+		if errors.Is(ctxErr, context.Canceled) {
+			return out, err
+		}
+		if ctxErr != nil && err == nil {
+			err = ctxErr
+		}
+
+		state := Done
+		if err != nil {
+			state = Failed
+		}
+		if finishErr := q.finish(in, attempt, state, err); finishErr != nil && err == nil {
+			err = fmt.Errorf("unable to checkpoint input: %w", finishErr)
+		}
+
+		return out, err
+	}
+}
+
+// Run executes the unfinished Inputs of the job with wp. For the duration of
+// the run the pool's Task.Execute is wrapped with Wrap, its Task.Input is
+// replaced by a channel carrying the Inputs returned by Resume and its
+// TotalInput is set to their number.
+//
+// Inputs left unprocessed because ctx was cancelled keep their Pending or
+// Running checkpoint and are picked up again by the next Run.
+func Run[In, Out any](ctx context.Context, q *Queue[In], wp *workerpool.WorkerPool[In, Out], retryFailed bool) (workerpool.Report[In], error) {
+	inputs, err := q.Resume(retryFailed)
+	if err != nil {
+		return workerpool.Report[In]{}, err
+	}
+
+	input := make(chan In, len(inputs))
+	for _, in := range inputs {
+		input <- in
+	}
+	close(input)
+
+	task, total := wp.Task, wp.TotalInput
+	defer func() {
+		wp.Task, wp.TotalInput = task, total
+	}()
+
+	wp.Task.Input = input
+	wp.Task.Execute = Wrap(q, task.Execute)
+	wp.TotalInput = len(inputs)
+
+	return wp.Run(ctx)
+}
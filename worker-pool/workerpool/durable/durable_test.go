@@ -0,0 +1,256 @@
+package durable_test
+
+import (
+	"context"
+	"errors"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/durable"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunResumes(t *testing.T) {
+	db, err := durable.OpenDb(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	q, err := durable.Open[int](db, "test", strconv.Itoa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(workerpooltest.Sequence(10)); err != nil {
+		t.Fatal(err)
+	}
+
+	errBoom := errors.New("boom")
+	script := workerpooltest.NewScript[int](workerpooltest.Succeed(struct{}{})).
+		On(4, workerpooltest.Fail[struct{}](errBoom), workerpooltest.Succeed(struct{}{}))
+	wp := workerpool.WorkerPool[int, struct{}]{
+		NumberOfWorkers: 3,
+		Task:            workerpool.Task[int, struct{}]{Execute: script.Execute},
+	}
+
+	tests := []struct {
+		name        string
+		retryFailed bool
+		wantErr     error
+		wantCounts  map[durable.State]int
+	}{
+		{
+			name:       "first run",
+			wantErr:    errBoom,
+			wantCounts: map[durable.State]int{durable.Done: 9, durable.Failed: 1},
+		},
+		{
+			name:       "resume skips failed inputs",
+			wantCounts: map[durable.State]int{durable.Done: 9, durable.Failed: 1},
+		},
+		{
+			name:        "resume retries failed inputs",
+			retryFailed: true,
+			wantCounts:  map[durable.State]int{durable.Done: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := durable.Run(context.Background(), q, &wp, tt.retryFailed)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			counts, err := q.Counts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for state, want := range tt.wantCounts {
+				if counts[state] != want {
+					t.Errorf("got %d %s inputs, want %d", counts[state], state, want)
+				}
+			}
+		})
+	}
+
+	for _, in := range workerpooltest.Sequence(10) {
+		want := 1
+		if in == 4 {
+			want = 2
+		}
+		if got := script.Calls(in); got != want {
+			t.Errorf("input %d: got %d calls, want %d", in, got, want)
+		}
+	}
+}
+
+func TestRunResumesAfterDrainTimeout(t *testing.T) {
+	db, err := durable.OpenDb(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	q, err := durable.Open[int](db, "test", strconv.Itoa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue([]int{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	inputs, err := q.Resume(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run the first attempt through Wrap directly, so the test can wait for
+	// the executions abandoned by the drain timeout to return.
+	clock := workerpooltest.NewClock(time.Now())
+	started := make(chan struct{}, 3)
+	script := workerpooltest.NewScript[int](func(ctx context.Context) (struct{}, error) {
+		started <- struct{}{}
+		return workerpooltest.Hang[struct{}]()(ctx)
+	})
+	wrapped := durable.Wrap(q, script.Execute)
+
+	var executing sync.WaitGroup
+	wp := workerpool.WorkerPool[int, struct{}]{
+		NumberOfWorkers: 3,
+		Task: workerpool.Task[int, struct{}]{
+			Input: workerpooltest.Feed(inputs...),
+			Execute: func(ctx context.Context, in int) (struct{}, error) {
+				executing.Add(1)
+				defer executing.Done()
+				return wrapped(ctx, in)
+			},
+		},
+		DrainTimeout: time.Minute,
+		Clock:        clock,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan workerpool.Report[int])
+	go func() {
+		rep, _ := wp.Run(ctx)
+		done <- rep
+	}()
+
+	for range inputs {
+		<-started
+	}
+	cancel()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	rep := <-done
+	executing.Wait()
+	workerpooltest.AssertSameElements(t, rep.Unprocessed, []int{1, 2, 3})
+
+	counts, err := q.Counts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[durable.Running] != 3 {
+		t.Errorf("got checkpoints %v, want 3 running", counts)
+	}
+
+	resumed, err := q.Resume(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workerpooltest.AssertOrder(t, resumed, []int{1, 2, 3})
+
+	// The abandoned Workers of the first run still use wp, so resume with a
+	// new pool.
+	resume := workerpool.WorkerPool[int, struct{}]{
+		NumberOfWorkers: 3,
+		Task: workerpool.Task[int, struct{}]{
+			Execute: workerpooltest.NewScript[int](workerpooltest.Succeed(struct{}{})).Execute,
+		},
+	}
+	if _, err := durable.Run(context.Background(), q, &resume, false); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err = q.Counts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[durable.Done] != 3 {
+		t.Errorf("got checkpoints %v, want 3 done", counts)
+	}
+}
+
+func TestWrapIgnoresAbandonedAttempts(t *testing.T) {
+	db, err := durable.OpenDb(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	q, err := durable.Open[int](db, "test", strconv.Itoa)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first attempt ignores its timeout and only fails once the retry
+	// succeeded.
+	clock := workerpooltest.NewClock(time.Now())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	script := workerpooltest.NewScript[int](workerpooltest.Succeed(struct{}{})).
+		On(1, func(ctx context.Context) (struct{}, error) {
+			close(started)
+			return workerpooltest.Block(release, workerpooltest.Fail[struct{}](errors.New("late")))(ctx)
+		}, workerpooltest.Succeed(struct{}{}))
+	wrapped := durable.Wrap(q, script.Execute)
+
+	var executing sync.WaitGroup
+	wp := workerpool.WorkerPool[int, struct{}]{
+		NumberOfWorkers: 1,
+		Task: workerpool.Task[int, struct{}]{
+			Input: workerpooltest.Feed(1),
+			Execute: func(ctx context.Context, in int) (struct{}, error) {
+				executing.Add(1)
+				defer executing.Done()
+				return wrapped(ctx, in)
+			},
+		},
+		TaskTimeout: time.Second,
+		Retry:       &workerpool.RetryPolicy{MaxAttempts: 2},
+		Clock:       clock,
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := wp.Run(context.Background())
+		done <- err
+	}()
+
+	<-started
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+	executing.Wait()
+
+	counts, err := q.Counts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[durable.Done] != 1 {
+		t.Errorf("got checkpoints %v, want 1 done", counts)
+	}
+}
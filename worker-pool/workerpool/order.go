@@ -0,0 +1,57 @@
+package workerpool
+
+import (
+	"sync"
+)
+
+// reorderer holds Results that completed ahead of earlier Inputs and releases
+// them in dispatch order.
+type reorderer[In, Out any] struct {
+	// slots bounds the number of Inputs dispatched but not yet emitted, which
+	// in turn bounds the number of Results held back in pending.
+	slots chan struct{}
+
+	mu      sync.Mutex
+	next    uint64
+	pending map[uint64]Result[In, Out]
+}
+
+func newReorderer[In, Out any](size int) *reorderer[In, Out] {
+	return &reorderer[In, Out]{
+		slots:   make(chan struct{}, size),
+		pending: make(map[uint64]Result[In, Out]),
+	}
+}
+
+// push records the Result for the Input with the given sequence number and
+// emits every Result that is now next in line.
+func (o *reorderer[In, Out]) push(seq uint64, res Result[In, Out], emit func(Result[In, Out])) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.pending[seq] = res
+	for {
+		res, ok := o.pending[o.next]
+		if !ok {
+			return
+		}
+
+		delete(o.pending, o.next)
+		o.next++
+		emit(res)
+		<-o.slots
+	}
+}
+
+// reorderBuffer returns the pool's ReorderBuffer, defaulting to four times its
+// initial number of Workers.
+func (wp *WorkerPool[In, Out]) reorderBuffer() int {
+	if wp.ReorderBuffer > 0 {
+		return wp.ReorderBuffer
+	}
+	if wp.NumberOfWorkers > 0 {
+		return 4 * wp.NumberOfWorkers
+	}
+
+	return 1
+}
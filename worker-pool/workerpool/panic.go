@@ -0,0 +1,57 @@
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error recorded for an Input whose Execute panicked.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("workerpool: task panicked: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+
+	return err
+}
+
+// safeExecute calls the Task's Execute, converting a panic into a PanicError.
+func (r *run[In, Out]) safeExecute(ctx context.Context, in In) (out Out, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.panics.Add(1)
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	return r.pool.Task.Execute(ctx, in)
+}
+
+// replace starts a new Worker, consuming the same lane, in place of one that
+// is exiting after a panic. It returns false if the run no longer accepts
+// Workers, in which case the exiting Worker should carry on instead.
+func (r *run[In, Out]) replace(lane int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.drained {
+		return false
+	}
+
+	r.wg.Add(1)
+	w := Worker[In, Out]{run: r, lane: lane}
+	go w.Work()
+
+	return true
+}
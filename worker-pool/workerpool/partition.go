@@ -0,0 +1,100 @@
+package workerpool
+
+import (
+	"errors"
+	"hash/fnv"
+)
+
+// ErrPartitioned is returned by Resize for a pool with a PartitionKey, whose
+// number of lanes is fixed for the duration of a run.
+var ErrPartitioned = errors.New("workerpool: cannot resize a partitioned pool")
+
+// defaultLaneBuffer is the number of Inputs each lane of a partitioned pool
+// buffers when the pool does not set LaneBuffer.
+const defaultLaneBuffer = 16
+
+// startLanes starts one Worker per lane of a partitioned run.
+func (r *run[In, Out]) startLanes(n int) {
+	buffer := r.pool.LaneBuffer
+	if buffer <= 0 {
+		buffer = defaultLaneBuffer
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lanes = make([]chan job[In], n)
+	r.target = n
+	r.active = n
+	for i := range r.lanes {
+		r.lanes[i] = make(chan job[In], buffer)
+
+		r.wg.Add(1)
+		w := Worker[In, Out]{run: r, lane: i}
+		go w.Work()
+	}
+}
+
+// laneFor returns the lane that every Input with the same key as in is
+// routed to.
+func (r *run[In, Out]) laneFor(in In) chan job[In] {
+	h := fnv.New32a()
+	h.Write([]byte(r.pool.PartitionKey(in)))
+
+	return r.lanes[h.Sum32()%uint32(len(r.lanes))]
+}
+
+// nextInLane returns the next Input routed to the given lane. It returns false
+// once the lane is closed or the run has stopped, leaving any Inputs still
+// buffered in the lane to be reported as unprocessed.
+func (r *run[In, Out]) nextInLane(lane int) (job[In], bool) {
+	if r.ctx.Err() == nil {
+		select {
+		case j, ok := <-r.lanes[lane]:
+			if ok {
+				return j, true
+			}
+		case <-r.ctx.Done():
+		}
+	}
+
+	r.mu.Lock()
+	r.active--
+	r.drained = true
+	r.mu.Unlock()
+
+	return job[In]{}, false
+}
+
+// collectLanes moves the Inputs left in the lanes of a stopped run to its
+// unprocessed list. The caller must hold r.mu.
+func (r *run[In, Out]) collectLanes() {
+	for _, lane := range r.lanes {
+		r.collectLane(lane)
+	}
+}
+
+func (r *run[In, Out]) collectLane(lane chan job[In]) {
+	for {
+		select {
+		case j, ok := <-lane:
+			if !ok {
+				return
+			}
+			r.unprocessed = append(r.unprocessed, j)
+		default:
+			return
+		}
+	}
+}
+
+// laneQueued returns the number of Inputs waiting in the lanes of a
+// partitioned run. The caller must hold r.mu.
+func (r *run[In, Out]) laneQueued() int {
+	n := 0
+	for _, lane := range r.lanes {
+		n += len(lane)
+	}
+
+	return n
+}
@@ -0,0 +1,169 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Pipeline chains WorkerPools into stages connected by bounded channels, such
+// as "read CSV -> call HTTP API -> write to DB":
+//
+//	p := workerpool.NewPipeline(ctx)
+//	rows := workerpool.Source(p, 100, readCsv)
+//	users := workerpool.Stage(p, rows, 100, &workerpool.WorkerPool[[]string, User]{
+//		NumberOfWorkers: 20,
+//		Task:            workerpool.Task[[]string, User]{Execute: fetchUser},
+//	})
+//	workerpool.Sink(p, users, &workerpool.WorkerPool[User, struct{}]{
+//		NumberOfWorkers: 5,
+//		Task:            workerpool.Task[User, struct{}]{Execute: saveUser},
+//	})
+//	err := p.Wait()
+//
+// Each stage runs with its own number of Workers. A full buffer blocks the
+// stage feeding it, so a slow stage slows down everything upstream. The first
+// error in any stage cancels the whole Pipeline, and cancelling the context
+// passed to NewPipeline stops every stage.
+type Pipeline struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	err    error
+	stages int
+}
+
+// NewPipeline returns an empty Pipeline bound to ctx.
+func NewPipeline(ctx context.Context) *Pipeline {
+	p := &Pipeline{parent: ctx}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	return p
+}
+
+// Context returns the Pipeline's context, which is cancelled as soon as any
+// stage fails.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Wait blocks until every stage has finished and returns the first error, if
+// any, that stopped the Pipeline, or the parent context's error if it was
+// cancelled.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	return p.parent.Err()
+}
+
+// fail records err as the reason the Pipeline stopped, unless an earlier error
+// was already recorded, and cancels every stage.
+func (p *Pipeline) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+
+	p.cancel()
+}
+
+// nextStage returns the name used for the next stage in error messages.
+func (p *Pipeline) nextStage() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stages++
+
+	return fmt.Sprintf("stage %d", p.stages)
+}
+
+// Source starts the first stage of p, which sends the values produced by
+// produce on the returned channel. produce must stop sending once ctx is
+// done; returning an error fails the Pipeline.
+func Source[T any](p *Pipeline, buffer int, produce func(ctx context.Context, out chan<- T) error) <-chan T {
+	name := p.nextStage()
+	out := make(chan T, buffer)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+
+		if err := produce(p.ctx, out); err != nil {
+			p.fail(fmt.Errorf("%s: %w", name, err))
+		}
+	}()
+
+	return out
+}
+
+// Stage adds wp as the next stage of p, consuming in and returning a channel
+// with room for buffer successful Outputs. The Pipeline sets the pool's
+// Task.Input and Results; every other setting of wp, such as retries, rate
+// limits or Ordered, applies as usual. An Input that ultimately fails fails
+// the Pipeline.
+func Stage[In, Out any](p *Pipeline, in <-chan In, buffer int, wp *WorkerPool[In, Out]) <-chan Out {
+	name := p.nextStage()
+	out := make(chan Out, buffer)
+	results := make(chan Result[In, Out])
+
+	wp.Task.Input = in
+	wp.Results = results
+
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+
+		_, err := wp.Run(p.ctx)
+		if errors.Is(err, ErrDrainTimeout) {
+			p.fail(fmt.Errorf("%s: %w", name, ErrDrainTimeout))
+		}
+	}()
+
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+
+		// Keep draining results after a failure so the pool can finish.
+		for res := range results {
+			if res.Err != nil {
+				p.fail(fmt.Errorf("%s: %w", name, Failure[In]{Input: res.Input, Err: res.Err, Attempts: res.Attempts}))
+				continue
+			}
+
+			select {
+			case out <- res.Output:
+			case <-p.ctx.Done():
+			}
+		}
+	}()
+
+	return out
+}
+
+// Sink adds wp as the final stage of p, consuming in and discarding its
+// Outputs.
+func Sink[In any](p *Pipeline, in <-chan In, wp *WorkerPool[In, struct{}]) {
+	out := Stage(p, in, 0, wp)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		for range out {
+		}
+	}()
+}
@@ -0,0 +1,83 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   *workerpooltest.Script[string, int]
+		wantErr error
+		wantSum int
+	}{
+		{
+			name:    "runs every stage",
+			parse:   workerpooltest.NewScript[string](workerpooltest.Succeed(1)),
+			wantSum: 100,
+		},
+		{
+			name: "stops on the first failure",
+			parse: workerpooltest.NewScript[string](workerpooltest.Succeed(1)).
+				On("10", workerpooltest.Fail[int](errBoom)),
+			wantErr: errBoom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := workerpool.NewPipeline(context.Background())
+
+			lines := workerpool.Source(p, 10, func(ctx context.Context, out chan<- string) error {
+				for i := 0; i < 100; i++ {
+					select {
+					case out <- strconv.Itoa(i):
+					case <-ctx.Done():
+						return nil
+					}
+				}
+				return nil
+			})
+
+			numbers := workerpool.Stage(p, lines, 10, &workerpool.WorkerPool[string, int]{
+				NumberOfWorkers: 4,
+				Task:            workerpool.Task[string, int]{Execute: tt.parse.Execute},
+			})
+
+			var (
+				mu  sync.Mutex
+				sum int
+			)
+			workerpool.Sink(p, numbers, &workerpool.WorkerPool[int, struct{}]{
+				NumberOfWorkers: 2,
+				Task: workerpool.Task[int, struct{}]{Execute: func(ctx context.Context, n int) (struct{}, error) {
+					mu.Lock()
+					sum += n
+					mu.Unlock()
+					return struct{}{}, nil
+				}},
+			})
+
+			err := p.Wait()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if sum != tt.wantSum {
+				t.Errorf("got sum %d, want %d", sum, tt.wantSum)
+			}
+			workerpooltest.AssertMaxConcurrency(t, tt.parse, 4)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package workerpool
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyFailures is returned by Run when the pool stopped because its
+// ErrorPolicy failure limit was reached.
+var ErrTooManyFailures = errors.New("workerpool: failure limit reached")
+
+// ErrorPolicy decides whether the pool keeps dispatching Inputs after the Task
+// fails.
+type ErrorPolicy struct {
+	// MaxFailures is the number of failed Inputs after which the pool stops
+	// dispatching and cancels the context of in-flight Inputs. Zero never
+	// stops.
+	MaxFailures int
+}
+
+var (
+	// ContinueOnError executes every Input and aggregates all failures.
+	ContinueOnError = ErrorPolicy{}
+
+	// StopOnFirstError stops the pool as soon as one Input fails, in the same
+	// way an errgroup cancels its context on the first error.
+	StopOnFirstError = ErrorPolicy{MaxFailures: 1}
+)
+
+// StopAfterErrors returns an ErrorPolicy that stops the pool once n Inputs have
+// failed.
+func StopAfterErrors(n int) ErrorPolicy {
+	return ErrorPolicy{MaxFailures: n}
+}
+
+// tripped reports whether failures has reached the policy's limit.
+func (p ErrorPolicy) tripped(failures int) bool {
+	return p.MaxFailures > 0 && failures >= p.MaxFailures
+}
+
+// Failure records an Input the Task failed to execute, along with its error.
+type Failure[In any] struct {
+	Input    In
+	Err      error
+	Attempts int
+}
+
+func (f Failure[In]) Error() string {
+	return fmt.Sprintf("input %v: %s", f.Input, f.Err)
+}
+
+func (f Failure[In]) Unwrap() error {
+	return f.Err
+}
+
+// Err joins every Failure in the Report into a single error. It returns nil if
+// no Input failed.
+func (r Report[In]) Err() error {
+	errs := make([]error, len(r.Failures))
+	for i, f := range r.Failures {
+		errs[i] = f
+	}
+
+	return errors.Join(errs...)
+}
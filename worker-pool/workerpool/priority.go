@@ -0,0 +1,260 @@
+package workerpool
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQueueClosed is returned when submitting to a closed PriorityQueue.
+var ErrQueueClosed = errors.New("workerpool: queue is closed")
+
+// PriorityQueue feeds a WorkerPool with the highest-priority item submitted
+// so far. Use its Input as the Task's Input:
+//
+//	q := workerpool.NewPriorityQueue[int](time.Second, nil)
+//	wp := workerpool.WorkerPool[int, string]{
+//		NumberOfWorkers: 10,
+//		Task: workerpool.Task[int, string]{
+//			Input:   q.Input(),
+//			Execute: workFunction,
+//		},
+//	}
+//	go wp.Run(ctx)
+//
+//	q.Submit(1, 0)  // bulk work
+//	q.Submit(2, 10) // jumps ahead of the bulk work still queued
+//	q.Close()
+//
+// Items with equal priority are delivered in submission order. With aging
+// enabled, an item's effective priority increases by one for every
+// agingInterval it has waited, so low-priority items are not starved by a
+// steady stream of high-priority ones.
+//
+// The pool's dispatcher holds on to the next item while it waits for a free
+// Worker, so one item may already have left the queue when a higher-priority
+// one is submitted.
+type PriorityQueue[In any] struct {
+	aging   time.Duration
+	clock   Clock
+	created time.Time
+
+	mu     sync.Mutex
+	items  priorityItems[In]
+	seq    uint64
+	closed bool
+
+	notify  chan struct{}
+	stopped chan struct{}
+	done    chan struct{}
+	out     chan In
+}
+
+// NewPriorityQueue returns an open PriorityQueue. An agingInterval of zero
+// disables aging. Waiting times are measured on clock, usually the pool's;
+// a nil clock means RealClock.
+func NewPriorityQueue[In any](agingInterval time.Duration, clock Clock) *PriorityQueue[In] {
+	if clock == nil {
+		clock = RealClock
+	}
+
+	q := &PriorityQueue[In]{
+		aging:   agingInterval,
+		clock:   clock,
+		created: clock.Now(),
+		notify:  make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+		out:     make(chan In),
+	}
+
+	go q.pump()
+
+	return q
+}
+
+// Input returns the channel the queue delivers items on, in priority order.
+// It is closed once the queue has been closed and emptied, or drained.
+func (q *PriorityQueue[In]) Input() <-chan In {
+	return q.out
+}
+
+// Submit queues item with the given priority. Higher priorities are
+// delivered first.
+func (q *PriorityQueue[In]) Submit(item In, priority int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	heap.Push(&q.items, &priorityItem[In]{
+		value: item,
+		key:   q.key(priority, q.clock.Now()),
+		seq:   q.seq,
+	})
+	q.seq++
+	q.wake()
+
+	return nil
+}
+
+// Len returns the number of items waiting in the queue.
+func (q *PriorityQueue[In]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.items.Len()
+}
+
+// Close stops accepting new items. Items already queued are still delivered,
+// after which Input is closed.
+func (q *PriorityQueue[In]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.wake()
+}
+
+// Drain closes the queue, stops delivering items and returns the ones that
+// were never delivered, highest priority first. It is typically used after
+// cancelling the pool to find out which items were not processed.
+func (q *PriorityQueue[In]) Drain() []In {
+	q.mu.Lock()
+	q.closed = true
+	select {
+	case <-q.stopped:
+	default:
+		close(q.stopped)
+	}
+	q.mu.Unlock()
+
+	// Wait for the pump so an item cannot be both delivered and returned.
+	<-q.done
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []In
+	for q.items.Len() > 0 {
+		items = append(items, heap.Pop(&q.items).(*priorityItem[In]).value)
+	}
+
+	return items
+}
+
+// key orders items so that the static value priority - age/aging, which
+// compares the same way as the effective priority at any point in time,
+// decides which item is delivered first.
+func (q *PriorityQueue[In]) key(priority int, now time.Time) int64 {
+	if q.aging <= 0 {
+		return int64(priority)
+	}
+
+	return int64(priority)*int64(q.aging) - int64(now.Sub(q.created))
+}
+
+// wake signals the pump that the queue changed. The caller must hold q.mu.
+func (q *PriorityQueue[In]) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pump delivers the highest-priority item on out, re-evaluating whenever the
+// queue changes while it waits for a receiver.
+func (q *PriorityQueue[In]) pump() {
+	defer close(q.done)
+	defer close(q.out)
+
+	for {
+		select {
+		case <-q.stopped:
+			return
+		default:
+		}
+
+		q.mu.Lock()
+		if q.items.Len() == 0 && q.closed {
+			q.mu.Unlock()
+			return
+		}
+
+		var (
+			top *priorityItem[In]
+			out chan In
+		)
+		if q.items.Len() > 0 {
+			top = q.items[0]
+			out = q.out
+		}
+		q.mu.Unlock()
+
+		var value In
+		if top != nil {
+			value = top.value
+		}
+
+		select {
+		case out <- value:
+			q.mu.Lock()
+			if top.index >= 0 {
+				heap.Remove(&q.items, top.index)
+			}
+			q.mu.Unlock()
+		case <-q.notify:
+		case <-q.stopped:
+			return
+		}
+	}
+}
+
+type priorityItem[In any] struct {
+	value In
+	key   int64
+	seq   uint64
+	index int
+}
+
+// priorityItems implements heap.Interface, highest key and then lowest seq
+// first.
+type priorityItems[In any] []*priorityItem[In]
+
+func (p priorityItems[In]) Len() int {
+	return len(p)
+}
+
+func (p priorityItems[In]) Less(i, j int) bool {
+	if p[i].key != p[j].key {
+		return p[i].key > p[j].key
+	}
+
+	return p[i].seq < p[j].seq
+}
+
+func (p priorityItems[In]) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+	p[i].index = i
+	p[j].index = j
+}
+
+func (p *priorityItems[In]) Push(x any) {
+	item := x.(*priorityItem[In])
+	item.index = len(*p)
+	*p = append(*p, item)
+}
+
+func (p *priorityItems[In]) Pop() any {
+	old := *p
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*p = old[:n-1]
+
+	return item
+}
@@ -0,0 +1,94 @@
+package workerpool_test
+
+import (
+	"errors"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	type item struct {
+		value    string
+		priority int
+	}
+
+	tests := []struct {
+		name  string
+		items []item
+		want  []string
+	}{
+		{
+			name:  "highest priority first",
+			items: []item{{"low", 0}, {"high", 10}, {"mid", 5}},
+			want:  []string{"high", "mid", "low"},
+		},
+		{
+			name:  "submission order within a priority",
+			items: []item{{"a", 1}, {"b", 1}, {"c", 2}, {"d", 1}},
+			want:  []string{"c", "a", "b", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := workerpool.NewPriorityQueue[string](0, nil)
+			for _, it := range tt.items {
+				if err := q.Submit(it.value, it.priority); err != nil {
+					t.Fatal(err)
+				}
+			}
+			q.Close()
+
+			var got []string
+			for v := range q.Input() {
+				got = append(got, v)
+			}
+
+			workerpooltest.AssertOrder(t, got, tt.want)
+		})
+	}
+}
+
+func TestPriorityQueueDrain(t *testing.T) {
+	q := workerpool.NewPriorityQueue[int](0, nil)
+	for _, p := range []int{1, 3, 2} {
+		if err := q.Submit(p, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workerpooltest.AssertOrder(t, q.Drain(), []int{3, 2, 1})
+
+	if _, ok := <-q.Input(); ok {
+		t.Error("Input is still open after Drain")
+	}
+	if err := q.Submit(4, 4); !errors.Is(err, workerpool.ErrQueueClosed) {
+		t.Errorf("got %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestPriorityQueueAging(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	q := workerpool.NewPriorityQueue[string](time.Minute, clock)
+
+	// Having waited three minutes, the bulk item outranks a new priority 2
+	// item but not a new priority 5 one.
+	for _, it := range []struct {
+		value    string
+		priority int
+		wait     time.Duration
+	}{
+		{"bulk", 0, 3 * time.Minute},
+		{"normal", 2, 0},
+		{"urgent", 5, 0},
+	} {
+		if err := q.Submit(it.value, it.priority); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(it.wait)
+	}
+
+	workerpooltest.AssertOrder(t, q.Drain(), []string{"urgent", "bulk", "normal"})
+}
@@ -0,0 +1,123 @@
+package workerpool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// ProgressReporter is a StatsReporter that is also told when the pool has
+// finished. When a pool's Reporter implements ProgressReporter, Finish is
+// called with the final Stats instead of a last call to Report.
+type ProgressReporter interface {
+	StatsReporter
+	Finish(s Stats)
+}
+
+// LogReporter writes one progress line per Stats snapshot to a log.Logger.
+type LogReporter struct {
+	logger *log.Logger
+}
+
+// NewLogReporter returns a LogReporter writing to logger, or to the standard
+// logger if logger is nil.
+func NewLogReporter(logger *log.Logger) *LogReporter {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return &LogReporter{logger: logger}
+}
+
+// Report logs a summary of s.
+func (l *LogReporter) Report(s Stats) {
+	l.logger.Printf(
+		"%d out of %d processed (%d failed, %d in flight, %d queued). %.1f/s, p50 %s, p99 %s.%s",
+		s.Processed, s.Total, s.Failed, s.InFlight, s.Queued, s.Throughput, s.Latency.Quantile(0.5), s.Latency.Quantile(0.99), breakerSummary(s),
+	)
+}
+
+// breakerSummary describes the pool's CircuitBreaker, if it is not closed.
+func breakerSummary(s Stats) string {
+	if s.Breaker == BreakerClosed {
+		return ""
+	}
+
+	return fmt.Sprintf(" Circuit breaker %s, %d short-circuited.", s.Breaker, s.ShortCircuited)
+}
+
+// Finish logs the final summary of s.
+func (l *LogReporter) Finish(s Stats) {
+	l.logger.Printf(
+		"Finished: %d processed, %d failed in %s. %.1f/s, mean %s, p99 %s.",
+		s.Processed, s.Failed, s.Elapsed.Round(time.Millisecond), s.Throughput, s.Latency.Mean(), s.Latency.Quantile(0.99),
+	)
+}
+
+// JSONReporter writes each Stats snapshot as a single line of JSON, suitable
+// for CI logs and other machine consumers.
+type JSONReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// jsonStats is the JSON line written by a JSONReporter. Durations are in
+// milliseconds.
+type jsonStats struct {
+	Time           time.Time `json:"time"`
+	Event          string    `json:"event"`
+	Total          int       `json:"total"`
+	Workers        int       `json:"workers"`
+	Processed      int       `json:"processed"`
+	Failed         int       `json:"failed"`
+	InFlight       int       `json:"in_flight"`
+	Panics         int       `json:"panics"`
+	Breaker        string    `json:"breaker"`
+	ShortCircuited int       `json:"short_circuited"`
+	Queued         int       `json:"queued"`
+	ElapsedMs      int64     `json:"elapsed_ms"`
+	Throughput     float64   `json:"throughput"`
+	LatencyP50Ms   int64     `json:"latency_p50_ms"`
+	LatencyP99Ms   int64     `json:"latency_p99_ms"`
+}
+
+// NewJSONReporter returns a JSONReporter writing to w.
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{enc: json.NewEncoder(w)}
+}
+
+// Report writes s as a "progress" event.
+func (j *JSONReporter) Report(s Stats) {
+	j.write("progress", s)
+}
+
+// Finish writes s as a "finish" event.
+func (j *JSONReporter) Finish(s Stats) {
+	j.write("finish", s)
+}
+
+func (j *JSONReporter) write(event string, s Stats) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_ = j.enc.Encode(jsonStats{
+		Time:           time.Now(),
+		Event:          event,
+		Total:          s.Total,
+		Workers:        s.Workers,
+		Processed:      s.Processed,
+		Failed:         s.Failed,
+		InFlight:       s.InFlight,
+		Panics:         s.Panics,
+		Breaker:        s.Breaker.String(),
+		ShortCircuited: s.ShortCircuited,
+		Queued:         s.Queued,
+		ElapsedMs:      s.Elapsed.Milliseconds(),
+		Throughput:     s.Throughput,
+		LatencyP50Ms:   s.Latency.Quantile(0.5).Milliseconds(),
+		LatencyP99Ms:   s.Latency.Quantile(0.99).Milliseconds(),
+	})
+}
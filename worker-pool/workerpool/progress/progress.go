@@ -0,0 +1,77 @@
+// Package progress provides a terminal progress bar for workerpool and picks
+// a suitable workerpool.ProgressReporter for the current output.
+//
+// Dependencies:
+// mpb: go get github.com/vbauerster/mpb/v7
+// go-isatty: go get github.com/mattn/go-isatty
+package progress
+
+import (
+	"github.com/mattn/go-isatty"
+	"github.com/vbauerster/mpb/v7"
+	"github.com/vbauerster/mpb/v7/decor"
+	"golang-library/worker-pool/workerpool"
+	"io"
+	"os"
+)
+
+// New returns a BarReporter when out is a terminal, and a
+// workerpool.JSONReporter otherwise so CI logs stay machine-readable.
+func New(out *os.File) workerpool.ProgressReporter {
+	if isatty.IsTerminal(out.Fd()) || isatty.IsCygwinTerminal(out.Fd()) {
+		return NewBarReporter(out)
+	}
+
+	return workerpool.NewJSONReporter(out)
+}
+
+// BarReporter renders the pool's progress as an mpb terminal bar. The bar is
+// only redrawn when the pool reports, so pair it with a short ReportInterval.
+type BarReporter struct {
+	out       io.Writer
+	p         *mpb.Progress
+	bar       *mpb.Bar
+	processed int
+}
+
+// NewBarReporter returns a BarReporter drawing to out.
+func NewBarReporter(out io.Writer) *BarReporter {
+	return &BarReporter{out: out}
+}
+
+// Report advances the bar to s.Processed.
+func (b *BarReporter) Report(s workerpool.Stats) {
+	if b.bar == nil {
+		b.start(s)
+	}
+
+	// Without a known total, stretch the bar to cover everything seen so far.
+	if s.Total == 0 {
+		b.bar.SetTotal(int64(s.Processed+s.InFlight+s.Queued), false)
+	}
+
+	b.bar.IncrBy(s.Processed - b.processed)
+	b.processed = s.Processed
+}
+
+// Finish completes the bar and waits for it to be fully rendered.
+func (b *BarReporter) Finish(s workerpool.Stats) {
+	b.Report(s)
+	b.bar.SetTotal(int64(s.Processed), true)
+	b.p.Wait()
+}
+
+// start initializes the progress bar.
+func (b *BarReporter) start(s workerpool.Stats) {
+	b.p = mpb.New(mpb.WithWidth(64), mpb.WithOutput(b.out))
+	name := "Processing:"
+	b.bar = b.p.AddBar(int64(s.Total),
+		mpb.PrependDecorators(
+			decor.Name(name, decor.WC{W: len(name) + 1, C: decor.DidentRight}),
+			decor.OnComplete(
+				decor.AverageETA(decor.ET_STYLE_GO, decor.WC{W: 8}), "Complete",
+			),
+		),
+		mpb.AppendDecorators(decor.Percentage()),
+	)
+}
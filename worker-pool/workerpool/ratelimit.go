@@ -0,0 +1,164 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that can be shared across every Worker of one
+// or more pools. Tokens are added at a fixed rate up to Burst, and every
+// execution of the Task consumes one.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing perSecond executions per
+// second on average, with bursts of up to burst executions. A perSecond of
+// zero or less disables limiting.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	return l.wait(ctx, RealClock)
+}
+
+// wait is Wait measured on c.
+func (l *RateLimiter) wait(ctx context.Context, c Clock) error {
+	d := l.reserve(c.Now())
+	if d <= 0 {
+		return nil
+	}
+
+	if !sleep(ctx, c, d) {
+		l.cancel()
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// reserve takes a token, going into debt if none is available, and returns how
+// long the caller must wait before the token is theirs.
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a token reserved by a caller that gave up waiting.
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+}
+
+// full reports whether the bucket has refilled completely, meaning it behaves
+// the same as a freshly created RateLimiter.
+func (l *RateLimiter) full(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+
+	return l.tokens >= l.burst
+}
+
+// refill adds the tokens accrued since the last refill. The first refill only
+// starts the clock, so the bucket starts full whichever Clock measures it.
+func (l *RateLimiter) refill(now time.Time) {
+	if l.last.IsZero() {
+		l.last = now
+	}
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rate
+		l.last = now
+	}
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// KeyedRateLimiter throttles Inputs that map to the same key independently of
+// Inputs with other keys, giving each key its own token bucket.
+type KeyedRateLimiter[In any] struct {
+	key       func(in In) string
+	perSecond float64
+	burst     int
+
+	mu        sync.Mutex
+	limiters  map[string]*RateLimiter
+	lastPrune time.Time
+}
+
+// NewKeyedRateLimiter returns a KeyedRateLimiter allowing perSecond executions
+// per second, with bursts of up to burst executions, for each key returned by
+// key.
+func NewKeyedRateLimiter[In any](perSecond float64, burst int, key func(in In) string) *KeyedRateLimiter[In] {
+	return &KeyedRateLimiter[In]{
+		key:       key,
+		perSecond: perSecond,
+		burst:     burst,
+		limiters:  make(map[string]*RateLimiter),
+	}
+}
+
+// Wait blocks until a token for in's key is available or ctx is done.
+func (k *KeyedRateLimiter[In]) Wait(ctx context.Context, in In) error {
+	return k.wait(ctx, in, RealClock)
+}
+
+// wait is Wait measured on c.
+func (k *KeyedRateLimiter[In]) wait(ctx context.Context, in In, c Clock) error {
+	return k.limiter(k.key(in), c.Now()).wait(ctx, c)
+}
+
+// limiter returns the RateLimiter for key, creating it if needed. Buckets that
+// have fully refilled are dropped at most once a second so idle keys do not
+// accumulate.
+func (k *KeyedRateLimiter[In]) limiter(key string, now time.Time) *RateLimiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.lastPrune.IsZero() {
+		k.lastPrune = now
+	}
+	if now.Sub(k.lastPrune) >= time.Second {
+		for name, l := range k.limiters {
+			if name != key && l.full(now) {
+				delete(k.limiters, name)
+			}
+		}
+		k.lastPrune = now
+	}
+
+	l, ok := k.limiters[key]
+	if !ok {
+		l = NewRateLimiter(k.perSecond, k.burst)
+		k.limiters[key] = l
+	}
+
+	return l
+}
@@ -0,0 +1,578 @@
+// Package remote spreads a workerpool job across several processes, on one
+// box or a LAN, over a small HTTP+JSON lease protocol.
+//
+// A Coordinator owns the job's Input and serves it to remote Workers, which
+// execute a workerpool Task exactly like a local WorkerPool would:
+//
+//	// Coordinator process.
+//	c := &remote.Coordinator[int, int]{Input: input, LeaseDuration: 30 * time.Second}
+//	go http.ListenAndServe(":8080", c)
+//	report, err := c.Run(ctx)
+//
+//	// Worker processes.
+//	w := remote.Worker[int, int]{
+//		URL:             "http://coordinator:8080",
+//		NumberOfWorkers: 10,
+//		Task:            workerpool.Task[int, int]{Execute: workFunction},
+//	}
+//	err := w.Run(ctx)
+//
+// The protocol has four endpoints, all POSTing JSON:
+//
+//	/lease      {"worker"}              -> 200 {"id", "input", "attempt", "lease_ms"},
+//	                                       204 when no Input is available yet,
+//	                                       410 once the job has finished
+//	/heartbeat  {"id"}                  -> 200 {"lease_ms"}, 409 if the lease was lost
+//	/complete   {"id", "output", "attempts"}   -> 200, 409 if the lease was lost
+//	/fail       {"id", "error", "attempts"}    -> 200, 409 if the lease was lost
+//
+// A lease that is not completed, failed or extended by a heartbeat within the
+// lease duration expires, and its Input is queued again for another worker.
+// Inputs and Outputs must be JSON encodable.
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"golang-library/worker-pool/workerpool"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Coordinator serves the Inputs of a job to remote Workers and collects their
+// outcomes. It is an http.Handler; Run must be called to wait for the job to
+// finish.
+type Coordinator[In, Out any] struct {
+	Input <-chan In
+
+	// Results, if set, receives the outcome of every Input. It must be
+	// received from until it is closed, when Run returns; once the Run
+	// context is done, outcomes that are not received are dropped.
+	Results chan workerpool.Result[In, Out]
+
+	// LeaseDuration is how long a Worker holds an Input without a heartbeat
+	// before it is handed to another Worker. It defaults to 30 seconds.
+	LeaseDuration time.Duration
+
+	// MaxLeases, if positive, fails an Input with ErrTooManyLeases once its
+	// lease has expired that many times, instead of queuing it again.
+	MaxLeases int
+
+	// LeaseWait is how long a lease request waits for an Input before
+	// answering that none is available yet. It defaults to one second.
+	LeaseWait time.Duration
+
+	// Clock measures lease expiry. It defaults to workerpool.RealClock.
+	Clock workerpool.Clock
+
+	mu          sync.Mutex
+	initialized bool
+	seq         uint64
+	pending     []*item[In]
+	leases      map[string]*lease[In]
+	inputClosed bool
+	stopped     bool
+	done        chan struct{}
+	processed   int
+	failures    []failure[In]
+	unprocessed []*item[In]
+
+	publishing    sync.WaitGroup
+	abandoned     chan struct{}
+	resultsMu     sync.RWMutex
+	resultsClosed bool
+}
+
+// item is a single Input tagged with its sequence number and the number of
+// times it was leased.
+type item[In any] struct {
+	seq    uint64
+	input  In
+	leases int
+}
+
+type lease[In any] struct {
+	item    *item[In]
+	worker  string
+	expires time.Time
+}
+
+type failure[In any] struct {
+	seq uint64
+	workerpool.Failure[In]
+}
+
+// Run waits until every Input has been completed or failed by a Worker, or
+// until ctx is done. Inputs not finished by then, including leased ones, are
+// reported as unprocessed and later requests for their leases fail.
+func (c *Coordinator[In, Out]) Run(ctx context.Context) (workerpool.Report[In], error) {
+	c.mu.Lock()
+	c.init()
+	// An Input closed before any lease request finishes the job right away.
+	// Later, serveLease and release close done as soon as they observe it.
+	c.checkDone()
+	done := c.done
+	c.mu.Unlock()
+
+wait:
+	for {
+		t := c.clock().NewTimer(c.leaseDuration() / 2)
+
+		select {
+		case <-done:
+			t.Stop()
+			break wait
+		case <-ctx.Done():
+			t.Stop()
+			break wait
+		case <-t.C():
+			c.publish(c.expire())
+		}
+	}
+
+	c.stop()
+	c.waitPublished(ctx)
+	c.closeResults()
+
+	rep := c.report()
+
+	return rep, errors.Join(ctx.Err(), rep.Err())
+}
+
+// ServeHTTP serves the lease protocol.
+func (c *Coordinator[In, Out]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, LeasePath):
+		c.serveLease(w, r)
+	case strings.HasSuffix(r.URL.Path, HeartbeatPath):
+		c.serveHeartbeat(w, r)
+	case strings.HasSuffix(r.URL.Path, CompletePath):
+		c.serveComplete(w, r)
+	case strings.HasSuffix(r.URL.Path, FailPath):
+		c.serveFail(w, r)
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (c *Coordinator[In, Out]) serveLease(w http.ResponseWriter, r *http.Request) {
+	var req leaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	c.publish(c.expire())
+
+	c.mu.Lock()
+	c.init()
+	if c.stopped || c.finished() {
+		c.mu.Unlock()
+		writeJSON(w, http.StatusGone, errorResponse{Error: "job finished"})
+		return
+	}
+	if len(c.pending) > 0 {
+		it := c.pending[0]
+		c.pending = c.pending[1:]
+		c.respondLease(w, it, req.Worker)
+		return
+	}
+	inputClosed, done := c.inputClosed, c.done
+	c.mu.Unlock()
+
+	if inputClosed {
+		// Leased Inputs may still expire and be queued again.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	t := c.clock().NewTimer(c.leaseWait())
+	defer t.Stop()
+
+	select {
+	case in, ok := <-c.Input:
+		c.mu.Lock()
+		if !ok {
+			// Wakes Run unless Inputs are still leased.
+			c.inputClosed = true
+			c.checkDone()
+			c.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		it := &item[In]{seq: c.seq, input: in}
+		c.seq++
+		if c.stopped {
+			c.unprocessed = append(c.unprocessed, it)
+			c.mu.Unlock()
+			writeJSON(w, http.StatusGone, errorResponse{Error: "job finished"})
+			return
+		}
+		c.respondLease(w, it, req.Worker)
+	case <-t.C():
+		w.WriteHeader(http.StatusNoContent)
+	case <-done:
+		writeJSON(w, http.StatusGone, errorResponse{Error: "job finished"})
+	case <-r.Context().Done():
+	}
+}
+
+// respondLease leases it to worker and writes the lease as the response. The
+// caller must hold c.mu, which respondLease releases.
+func (c *Coordinator[In, Out]) respondLease(w http.ResponseWriter, it *item[In], worker string) {
+	id, err := newLeaseID()
+	if err != nil {
+		c.pending = append([]*item[In]{it}, c.pending...)
+		c.mu.Unlock()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	it.leases++
+	c.leases[id] = &lease[In]{
+		item:    it,
+		worker:  worker,
+		expires: c.clock().Now().Add(c.leaseDuration()),
+	}
+	c.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, leaseResponse[In]{
+		ID:      id,
+		Input:   it.input,
+		Attempt: it.leases,
+		LeaseMs: c.leaseDuration().Milliseconds(),
+	})
+}
+
+func (c *Coordinator[In, Out]) serveHeartbeat(w http.ResponseWriter, r *http.Request) {
+	var req heartbeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	c.publish(c.expire())
+
+	c.mu.Lock()
+	c.init()
+	l, ok := c.leases[req.ID]
+	if ok {
+		l.expires = c.clock().Now().Add(c.leaseDuration())
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusConflict, ErrLeaseLost)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, heartbeatResponse{LeaseMs: c.leaseDuration().Milliseconds()})
+}
+
+func (c *Coordinator[In, Out]) serveComplete(w http.ResponseWriter, r *http.Request) {
+	var req completeRequest[Out]
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	it, ok := c.release(req.ID, nil, req.Attempts)
+	if !ok {
+		writeError(w, http.StatusConflict, ErrLeaseLost)
+		return
+	}
+
+	c.publish([]workerpool.Result[In, Out]{{Input: it.input, Output: req.Output, Attempts: req.Attempts}})
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (c *Coordinator[In, Out]) serveFail(w http.ResponseWriter, r *http.Request) {
+	var req failRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err := errors.New(req.Error)
+	it, ok := c.release(req.ID, err, req.Attempts)
+	if !ok {
+		writeError(w, http.StatusConflict, ErrLeaseLost)
+		return
+	}
+
+	c.publish([]workerpool.Result[In, Out]{{Input: it.input, Err: err, Attempts: req.Attempts}})
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+// release ends the lease with the given ID, recording the Input as processed
+// and, if err is not nil, failed. It returns false if the lease was lost.
+func (c *Coordinator[In, Out]) release(id string, err error, attempts int) (*item[In], bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.init()
+	l, ok := c.leases[id]
+	if !ok || c.stopped {
+		return nil, false
+	}
+
+	delete(c.leases, id)
+	c.processed++
+	if c.Results != nil {
+		c.publishing.Add(1)
+	}
+	if err != nil {
+		c.failures = append(c.failures, failure[In]{
+			seq:     l.item.seq,
+			Failure: workerpool.Failure[In]{Input: l.item.input, Err: err, Attempts: attempts},
+		})
+	}
+	c.checkDone()
+
+	return l.item, true
+}
+
+// expire ends the leases that have expired, queuing their Inputs again or
+// failing them once they reach MaxLeases. It returns the Results of the
+// failed Inputs.
+func (c *Coordinator[In, Out]) expire() []workerpool.Result[In, Out] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.init()
+	if c.stopped {
+		return nil
+	}
+
+	now := c.clock().Now()
+
+	var expired []*item[In]
+	for id, l := range c.leases {
+		if now.Before(l.expires) {
+			continue
+		}
+		delete(c.leases, id)
+		expired = append(expired, l.item)
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].seq < expired[j].seq })
+
+	var results []workerpool.Result[In, Out]
+	var requeued []*item[In]
+	for _, it := range expired {
+		if c.MaxLeases > 0 && it.leases >= c.MaxLeases {
+			c.processed++
+			c.failures = append(c.failures, failure[In]{
+				seq:     it.seq,
+				Failure: workerpool.Failure[In]{Input: it.input, Err: ErrTooManyLeases, Attempts: it.leases},
+			})
+			results = append(results, workerpool.Result[In, Out]{Input: it.input, Err: ErrTooManyLeases, Attempts: it.leases})
+			continue
+		}
+		requeued = append(requeued, it)
+	}
+
+	// Expired Inputs go first, as they have already waited the longest.
+	c.pending = append(requeued, c.pending...)
+	if c.Results != nil && len(results) > 0 {
+		c.publishing.Add(1)
+	}
+	c.checkDone()
+
+	return results
+}
+
+// stop ends the job, marking every Input not finished yet as unprocessed.
+func (c *Coordinator[In, Out]) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return
+	}
+	c.stopped = true
+
+	c.unprocessed = append(c.unprocessed, c.pending...)
+	c.pending = nil
+	for id, l := range c.leases {
+		c.unprocessed = append(c.unprocessed, l.item)
+		delete(c.leases, id)
+	}
+
+	c.collectBuffered()
+	c.checkDone()
+}
+
+// pollInput checks, without blocking, whether Input has been closed or has a
+// value ready, which is queued. It lets an idle job notice that it has
+// finished without waiting for the next lease request. The caller must hold
+// c.mu.
+func (c *Coordinator[In, Out]) pollInput() {
+	select {
+	case in, ok := <-c.Input:
+		if !ok {
+			c.inputClosed = true
+			return
+		}
+		c.pending = append(c.pending, &item[In]{seq: c.seq, input: in})
+		c.seq++
+	default:
+	}
+}
+
+// collectBuffered drains, without blocking, any values left in Input. The
+// caller must hold c.mu.
+func (c *Coordinator[In, Out]) collectBuffered() {
+	for !c.inputClosed {
+		select {
+		case in, ok := <-c.Input:
+			if !ok {
+				c.inputClosed = true
+				return
+			}
+			c.unprocessed = append(c.unprocessed, &item[In]{seq: ^uint64(0), input: in})
+		default:
+			return
+		}
+	}
+}
+
+// publish sends results, returned by release or expire, on Results, if set,
+// unless the Run context is done.
+func (c *Coordinator[In, Out]) publish(results []workerpool.Result[In, Out]) {
+	if c.Results == nil || len(results) == 0 {
+		return
+	}
+	defer c.publishing.Done()
+
+	c.resultsMu.RLock()
+	defer c.resultsMu.RUnlock()
+
+	if c.resultsClosed {
+		return
+	}
+	for _, res := range results {
+		select {
+		case c.Results <- res:
+		case <-c.abandoned:
+			return
+		}
+	}
+}
+
+// waitPublished waits for the Results of the Inputs released so far to be
+// sent, or dropped once ctx is done. The job must have been stopped, so that
+// no more Inputs are released.
+func (c *Coordinator[In, Out]) waitPublished(ctx context.Context) {
+	published := make(chan struct{})
+	go func() {
+		c.publishing.Wait()
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-ctx.Done():
+		close(c.abandoned)
+		<-published
+	}
+}
+
+func (c *Coordinator[In, Out]) closeResults() {
+	if c.Results == nil {
+		return
+	}
+
+	c.resultsMu.Lock()
+	defer c.resultsMu.Unlock()
+
+	c.resultsClosed = true
+	close(c.Results)
+}
+
+func (c *Coordinator[In, Out]) report() workerpool.Report[In] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sort.SliceStable(c.unprocessed, func(i, j int) bool { return c.unprocessed[i].seq < c.unprocessed[j].seq })
+	sort.SliceStable(c.failures, func(i, j int) bool { return c.failures[i].seq < c.failures[j].seq })
+
+	rep := workerpool.Report[In]{Processed: c.processed}
+	for _, it := range c.unprocessed {
+		rep.Unprocessed = append(rep.Unprocessed, it.input)
+	}
+	for _, f := range c.failures {
+		rep.Failures = append(rep.Failures, f.Failure)
+	}
+
+	return rep
+}
+
+// init prepares the Coordinator's state on first use. The caller must hold
+// c.mu.
+func (c *Coordinator[In, Out]) init() {
+	if c.initialized {
+		return
+	}
+
+	c.initialized = true
+	c.leases = make(map[string]*lease[In])
+	c.done = make(chan struct{})
+	c.abandoned = make(chan struct{})
+}
+
+// finished reports whether every Input has been completed or failed. The
+// caller must hold c.mu.
+func (c *Coordinator[In, Out]) finished() bool {
+	return c.inputClosed && len(c.pending) == 0 && len(c.leases) == 0
+}
+
+// checkDone closes done once the job has finished or stopped. The caller
+// must hold c.mu.
+func (c *Coordinator[In, Out]) checkDone() {
+	if !c.stopped && !c.inputClosed && len(c.pending) == 0 && len(c.leases) == 0 {
+		c.pollInput()
+	}
+	if !c.finished() && !c.stopped {
+		return
+	}
+
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+}
+
+func (c *Coordinator[In, Out]) clock() workerpool.Clock {
+	if c.Clock == nil {
+		return workerpool.RealClock
+	}
+
+	return c.Clock
+}
+
+func (c *Coordinator[In, Out]) leaseDuration() time.Duration {
+	if c.LeaseDuration <= 0 {
+		return 30 * time.Second
+	}
+
+	return c.LeaseDuration
+}
+
+func (c *Coordinator[In, Out]) leaseWait() time.Duration {
+	if c.LeaseWait <= 0 {
+		return time.Second
+	}
+
+	return c.LeaseWait
+}
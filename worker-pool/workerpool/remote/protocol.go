@@ -0,0 +1,122 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Paths of the endpoints served by a Coordinator. Every endpoint accepts a
+// POST with a JSON body and answers with JSON.
+const (
+	LeasePath     = "/lease"
+	HeartbeatPath = "/heartbeat"
+	CompletePath  = "/complete"
+	FailPath      = "/fail"
+)
+
+var (
+	// ErrLeaseLost is returned when a lease has expired, and its Input was
+	// handed to another worker, or is unknown to the Coordinator.
+	ErrLeaseLost = errors.New("remote: lease expired or unknown")
+
+	// ErrTooManyLeases is recorded for an Input whose lease expired
+	// Coordinator.MaxLeases times.
+	ErrTooManyLeases = errors.New("remote: lease expired too many times")
+)
+
+type leaseRequest struct {
+	Worker string `json:"worker"`
+}
+
+type leaseResponse[In any] struct {
+	ID      string `json:"id"`
+	Input   In     `json:"input"`
+	Attempt int    `json:"attempt"`
+	LeaseMs int64  `json:"lease_ms"`
+}
+
+type heartbeatRequest struct {
+	ID string `json:"id"`
+}
+
+type heartbeatResponse struct {
+	LeaseMs int64 `json:"lease_ms"`
+}
+
+type completeRequest[Out any] struct {
+	ID       string `json:"id"`
+	Output   Out    `json:"output"`
+	Attempts int    `json:"attempts"`
+}
+
+type failRequest struct {
+	ID       string `json:"id"`
+	Error    string `json:"error"`
+	Attempts int    `json:"attempts"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// newLeaseID returns a random lease ID.
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate lease ID: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// writeJSON writes v as the JSON body of a response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err as the JSON body of a response with the given status.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// post sends req as JSON to url and decodes a successful response into resp,
+// if it is not nil. It returns the response's status code.
+func post(ctx context.Context, client *http.Client, url string, req, resp any) (int, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("unable to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("unable to reach coordinator: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	switch {
+	case httpResp.StatusCode == http.StatusOK && resp != nil:
+		if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+			return httpResp.StatusCode, fmt.Errorf("unable to decode response: %w", err)
+		}
+	case httpResp.StatusCode >= http.StatusBadRequest && httpResp.StatusCode != http.StatusConflict && httpResp.StatusCode != http.StatusGone:
+		var e errorResponse
+		_ = json.NewDecoder(httpResp.Body).Decode(&e)
+		return httpResp.StatusCode, fmt.Errorf("coordinator responded %s: %s", httpResp.Status, e.Error)
+	}
+
+	return httpResp.StatusCode, nil
+}
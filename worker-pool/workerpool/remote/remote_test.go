@@ -0,0 +1,382 @@
+package remote_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/remote"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkers(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name       string
+		workers    int
+		lease      time.Duration
+		script     *workerpooltest.Script[int, int]
+		wantFailed []int
+	}{
+		{
+			name:    "single worker",
+			workers: 1,
+			lease:   time.Minute,
+			script:  workerpooltest.NewScript[int](workerpooltest.Succeed(1)),
+		},
+		{
+			name:    "several workers with failures",
+			workers: 3,
+			lease:   time.Minute,
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)).
+				On(7, workerpooltest.Fail[int](errBoom)).
+				On(21, workerpooltest.Panic[int]("boom")),
+			wantFailed: []int{7, 21},
+		},
+		{
+			name:    "heartbeats keep slow leases",
+			workers: 2,
+			lease:   60 * time.Millisecond,
+			script: workerpooltest.NewScript[int](func(ctx context.Context) (int, error) {
+				time.Sleep(100 * time.Millisecond)
+				return 1, nil
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs := workerpooltest.Sequence(30)
+			if tt.lease < time.Second {
+				inputs = workerpooltest.Sequence(4)
+			}
+
+			results := make(chan workerpool.Result[int, int])
+			collected := workerpooltest.Collect(results)
+			c := &remote.Coordinator[int, int]{
+				Input:         workerpooltest.Feed(inputs...),
+				Results:       results,
+				LeaseDuration: tt.lease,
+				LeaseWait:     10 * time.Millisecond,
+			}
+
+			srv := httptest.NewServer(c)
+			defer srv.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			errs := make(chan error, 2)
+			for i := 0; i < 2; i++ {
+				w := remote.Worker[int, int]{
+					URL:             srv.URL,
+					NumberOfWorkers: tt.workers,
+					Task:            workerpool.Task[int, int]{Execute: tt.script.Execute},
+					PollInterval:    10 * time.Millisecond,
+				}
+				go func() {
+					errs <- w.Run(ctx)
+				}()
+			}
+
+			rep, err := c.Run(ctx)
+			if len(tt.wantFailed) == 0 && err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			for i := 0; i < 2; i++ {
+				if err := <-errs; err != nil {
+					t.Errorf("worker: %v", err)
+				}
+			}
+
+			if rep.Processed != len(inputs) || len(rep.Unprocessed) != 0 {
+				t.Errorf("got %d processed and %d unprocessed, want %d processed", rep.Processed, len(rep.Unprocessed), len(inputs))
+			}
+
+			var failed []int
+			for _, f := range rep.Failures {
+				failed = append(failed, f.Input)
+			}
+			workerpooltest.AssertOrder(t, failed, tt.wantFailed)
+			workerpooltest.AssertSameElements(t, workerpooltest.Inputs(collected()), inputs)
+			workerpooltest.AssertSameElements(t, tt.script.Started(), inputs)
+		})
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLeases int
+		check     func(t *testing.T, p protocol, first lease)
+		wantErr   error
+	}{
+		{
+			name: "expired lease is handed out again",
+			check: func(t *testing.T, p protocol, first lease) {
+				second, status := p.lease()
+				if status != http.StatusOK || second.Input != first.Input || second.Attempt != 2 {
+					t.Fatalf("got %+v (%d), want input %d again", second, status, first.Input)
+				}
+
+				if status := p.call(remote.CompletePath, map[string]any{"id": first.ID, "output": 1}); status != http.StatusConflict {
+					t.Errorf("completing the expired lease: got status %d, want %d", status, http.StatusConflict)
+				}
+				if status := p.call(remote.HeartbeatPath, map[string]any{"id": second.ID}); status != http.StatusOK {
+					t.Errorf("heartbeat: got status %d", status)
+				}
+				if status := p.call(remote.CompletePath, map[string]any{"id": second.ID, "output": 1}); status != http.StatusOK {
+					t.Errorf("completing the new lease: got status %d", status)
+				}
+			},
+		},
+		{
+			name:      "fails after max leases",
+			maxLeases: 1,
+			check: func(t *testing.T, p protocol, first lease) {
+				if _, status := p.lease(); status != http.StatusGone {
+					t.Errorf("got status %d, want %d", status, http.StatusGone)
+				}
+			},
+			wantErr: remote.ErrTooManyLeases,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := workerpooltest.NewClock(time.Now())
+			c := &remote.Coordinator[int, int]{
+				Input:         workerpooltest.Feed(42),
+				LeaseDuration: time.Minute,
+				MaxLeases:     tt.maxLeases,
+				Clock:         clock,
+			}
+
+			srv := httptest.NewServer(c)
+			defer srv.Close()
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := c.Run(context.Background())
+				done <- err
+			}()
+
+			p := protocol{t: t, url: srv.URL}
+			first, status := p.lease()
+			if status != http.StatusOK || first.Input != 42 || first.Attempt != 1 {
+				t.Fatalf("got %+v (%d), want input 42", first, status)
+			}
+
+			clock.Advance(time.Minute)
+			tt.check(t, p, first)
+
+			err := <-done
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkerRetriesLease(t *testing.T) {
+	c := &remote.Coordinator[int, int]{
+		Input:     workerpooltest.Feed(workerpooltest.Sequence(3)...),
+		LeaseWait: 10 * time.Millisecond,
+	}
+
+	// The first lease requests fail as if the Coordinator were restarting.
+	var (
+		mu       sync.Mutex
+		failures = 3
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fail := strings.HasSuffix(r.URL.Path, remote.LeasePath) && failures > 0
+		if fail {
+			failures--
+		}
+		mu.Unlock()
+
+		if fail {
+			http.Error(w, "restarting", http.StatusServiceUnavailable)
+			return
+		}
+		c.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	w := remote.Worker[int, int]{
+		URL:          srv.URL,
+		Task:         workerpool.Task[int, int]{Execute: workerpooltest.NewScript[int](workerpooltest.Succeed(1)).Execute},
+		PollInterval: 10 * time.Millisecond,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- w.Run(ctx)
+	}()
+
+	rep, err := c.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("worker: %v", err)
+	}
+	if rep.Processed != 3 {
+		t.Errorf("got %d processed, want 3", rep.Processed)
+	}
+}
+
+func TestCoordinatorClosedInput(t *testing.T) {
+	// Without lease requests, expired leases are only checked every half
+	// hour.
+	c := &remote.Coordinator[int, int]{Input: workerpooltest.Feed[int](), LeaseDuration: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rep, err := c.Run(ctx)
+	if err != nil {
+		t.Fatalf("got error %v, want the job finished", err)
+	}
+	if rep.Processed != 0 || len(rep.Unprocessed) != 0 {
+		t.Errorf("got %d processed and %d unprocessed, want none", rep.Processed, len(rep.Unprocessed))
+	}
+}
+
+func TestCoordinatorCancel(t *testing.T) {
+	c := &remote.Coordinator[int, int]{Input: workerpooltest.Feed(1, 2, 3)}
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	p := protocol{t: t, url: srv.URL}
+	l, _ := p.lease()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rep, err := c.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+	workerpooltest.AssertOrder(t, rep.Unprocessed, []int{1, 2, 3})
+
+	if status := p.call(remote.CompletePath, map[string]any{"id": l.ID}); status != http.StatusConflict {
+		t.Errorf("completing after Run returned: got status %d, want %d", status, http.StatusConflict)
+	}
+	if _, status := p.lease(); status != http.StatusGone {
+		t.Errorf("leasing after Run returned: got status %d, want %d", status, http.StatusGone)
+	}
+}
+
+func TestCoordinatorStalledResults(t *testing.T) {
+	// Nobody receives from Results.
+	results := make(chan workerpool.Result[int, int])
+	c := &remote.Coordinator[int, int]{
+		Input:     workerpooltest.Feed(1),
+		Results:   results,
+		LeaseWait: 10 * time.Millisecond,
+	}
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	p := protocol{t: t, url: srv.URL}
+	l, _ := p.lease()
+
+	completed := make(chan int)
+	go func() {
+		resp, err := http.Post(srv.URL+remote.CompletePath, "application/json",
+			bytes.NewReader([]byte(`{"id": "`+l.ID+`", "output": 1}`)))
+		if err != nil {
+			completed <- 0
+			return
+		}
+		resp.Body.Close()
+		completed <- resp.StatusCode
+	}()
+
+	// The job is finished once the Input has been released, after which the
+	// completion waits for its Result to be received.
+	for {
+		if _, status := p.lease(); status == http.StatusGone {
+			break
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rep, _ := c.Run(ctx)
+	if rep.Processed != 1 {
+		t.Errorf("got %d processed, want 1", rep.Processed)
+	}
+	if status := <-completed; status != http.StatusOK {
+		t.Errorf("completing: got status %d, want %d", status, http.StatusOK)
+	}
+	if _, ok := <-results; ok {
+		t.Error("Results is still open after Run returned")
+	}
+}
+
+type lease struct {
+	ID      string `json:"id"`
+	Input   int    `json:"input"`
+	Attempt int    `json:"attempt"`
+}
+
+// protocol speaks the lease protocol directly, the way a client in another
+// language would.
+type protocol struct {
+	t   *testing.T
+	url string
+}
+
+func (p protocol) lease() (lease, int) {
+	var l lease
+	status := p.do(remote.LeasePath, map[string]any{"worker": "test"}, &l)
+
+	return l, status
+}
+
+func (p protocol) call(path string, req any) int {
+	return p.do(path, req, nil)
+}
+
+func (p protocol) do(path string, req, resp any) int {
+	p.t.Helper()
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	httpResp, err := http.Post(p.url+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode == http.StatusOK && resp != nil {
+		if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+			p.t.Fatal(err)
+		}
+	}
+
+	return httpResp.StatusCode
+}
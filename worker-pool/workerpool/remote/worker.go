@@ -0,0 +1,321 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"golang-library/worker-pool/workerpool"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Worker executes a Task against Inputs leased from a Coordinator, running
+// NumberOfWorkers executions at a time. The Task's Execute, Retry and Timeout
+// apply as they would in a local WorkerPool; its Input is ignored.
+//
+// Every leased Input is kept alive with heartbeats until its outcome has been
+// reported. If the Coordinator reports the lease as lost, the context passed
+// to Execute is cancelled.
+type Worker[In, Out any] struct {
+	// URL is the base URL the Coordinator is served at.
+	URL string
+
+	// Name identifies the Worker to the Coordinator. It defaults to the host
+	// name and process ID.
+	Name string
+
+	NumberOfWorkers int
+	Task            workerpool.Task[In, Out]
+
+	// Client sends requests to the Coordinator. It defaults to
+	// http.DefaultClient.
+	Client *http.Client
+
+	// PollInterval is how long the Worker waits before asking for an Input
+	// again after none was available. It defaults to one second.
+	PollInterval time.Duration
+}
+
+// leased is an Input leased from the Coordinator.
+type leased[In any] struct {
+	id       string
+	input    In
+	duration time.Duration
+
+	// lost is closed when the Coordinator reports the lease as lost, and
+	// released once its outcome has been reported.
+	lost     chan struct{}
+	released chan struct{}
+}
+
+// Run leases and executes Inputs until the Coordinator reports the job as
+// finished or ctx is done, retrying while the Coordinator cannot be reached.
+// Inputs that fail are reported to the Coordinator rather than returned; Run
+// only returns an error if ctx is done or an outcome could not be reported.
+func (w *Worker[In, Out]) Run(ctx context.Context) error {
+	size := w.NumberOfWorkers
+	if size < 1 {
+		size = 1
+	}
+
+	// A lease is only taken when a local Worker is free to execute it.
+	slots := make(chan struct{}, size)
+	leases := make(chan *leased[In])
+	results := make(chan workerpool.Result[*leased[In], Out])
+
+	wp := workerpool.WorkerPool[*leased[In], Out]{
+		NumberOfWorkers: size,
+		Task: workerpool.Task[*leased[In], Out]{
+			Input:   leases,
+			Execute: w.execute,
+			Retry:   w.Task.Retry,
+			Timeout: w.Task.Timeout,
+		},
+		Results: results,
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	go func() {
+		defer close(leases)
+
+		for {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			// A Coordinator that cannot be reached is asked again after the
+			// poll interval, like one with no Input available: it may be
+			// restarting.
+			l, finished, err := w.lease(ctx)
+			switch {
+			case finished:
+				return
+			case err != nil || l == nil:
+				<-slots
+				if !sleep(ctx, w.pollInterval()) {
+					return
+				}
+				continue
+			}
+
+			go w.heartbeat(ctx, l)
+
+			select {
+			case leases <- l:
+			case <-ctx.Done():
+				// The lease expires on the Coordinator.
+				close(l.released)
+				return
+			}
+		}
+	}()
+
+	reported := make(chan struct{})
+	go func() {
+		defer close(reported)
+
+		for res := range results {
+			if err := w.report(res); err != nil {
+				fail(err)
+			}
+			close(res.Input.released)
+			<-slots
+		}
+	}()
+
+	// Failed Inputs were reported to the Coordinator, so only the pool being
+	// stopped is an error here. Leases left unprocessed expire on the
+	// Coordinator.
+	rep, _ := wp.Run(ctx)
+	<-reported
+	for _, l := range rep.Unprocessed {
+		close(l.released)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
+
+// execute runs the Task against the leased Input, cancelling it if the lease
+// is lost.
+func (w *Worker[In, Out]) execute(ctx context.Context, l *leased[In]) (Out, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-l.lost:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return w.Task.Execute(ctx, l.input)
+}
+
+// lease asks the Coordinator for an Input. It returns a nil lease if none is
+// available yet, and finished once the job has finished.
+func (w *Worker[In, Out]) lease(ctx context.Context) (*leased[In], bool, error) {
+	var resp leaseResponse[In]
+	status, err := post(ctx, w.client(), w.url(LeasePath), leaseRequest{Worker: w.name()}, &resp)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to lease input: %w", err)
+	}
+
+	switch status {
+	case http.StatusOK:
+		return &leased[In]{
+			id:       resp.ID,
+			input:    resp.Input,
+			duration: time.Duration(resp.LeaseMs) * time.Millisecond,
+			lost:     make(chan struct{}),
+			released: make(chan struct{}),
+		}, false, nil
+	case http.StatusGone:
+		return nil, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// heartbeat extends the lease of l every third of the lease duration until
+// its outcome has been reported or ctx is done, closing l.lost if the lease
+// is lost. Each heartbeat must be answered within that interval.
+func (w *Worker[In, Out]) heartbeat(ctx context.Context, l *leased[In]) {
+	interval := l.duration / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for {
+		t := time.NewTimer(interval)
+		select {
+		case <-l.released:
+			t.Stop()
+			return
+		case <-ctx.Done():
+			// The lease expires on the Coordinator unless the outcome is
+			// reported first.
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		var resp heartbeatResponse
+		reqCtx, cancel := context.WithTimeout(ctx, interval)
+		status, err := post(reqCtx, w.client(), w.url(HeartbeatPath), heartbeatRequest{ID: l.id}, &resp)
+		cancel()
+		switch {
+		case err != nil:
+			// Keep trying until the lease expires; the Coordinator decides
+			// when it is lost.
+			continue
+		case status == http.StatusConflict || status == http.StatusGone:
+			close(l.lost)
+			return
+		}
+
+		if resp.LeaseMs > 0 {
+			interval = time.Duration(resp.LeaseMs) * time.Millisecond / 3
+		}
+	}
+}
+
+// report sends the outcome of an Input to the Coordinator. A lost lease is
+// not an error: the Input has been handed to another Worker.
+//
+// The outcomes of Inputs finished after the Worker's context was cancelled
+// are still reported, so the request is only bounded by the lease duration,
+// after which the lease would be lost anyway.
+func (w *Worker[In, Out]) report(res workerpool.Result[*leased[In], Out]) error {
+	var (
+		path string
+		req  any
+	)
+	if res.Err != nil {
+		path, req = FailPath, failRequest{ID: res.Input.id, Error: res.Err.Error(), Attempts: res.Attempts}
+	} else {
+		path, req = CompletePath, completeRequest[Out]{ID: res.Input.id, Output: res.Output, Attempts: res.Attempts}
+	}
+
+	timeout := res.Input.duration
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := post(ctx, w.client(), w.url(path), req, nil); err != nil {
+		return fmt.Errorf("unable to report outcome: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Worker[In, Out]) url(path string) string {
+	return strings.TrimSuffix(w.URL, "/") + path
+}
+
+func (w *Worker[In, Out]) client() *http.Client {
+	if w.Client == nil {
+		return http.DefaultClient
+	}
+
+	return w.Client
+}
+
+func (w *Worker[In, Out]) name() string {
+	if w.Name != "" {
+		return w.Name
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func (w *Worker[In, Out]) pollInterval() time.Duration {
+	if w.PollInterval <= 0 {
+		return time.Second
+	}
+
+	return w.PollInterval
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
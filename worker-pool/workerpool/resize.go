@@ -0,0 +1,198 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNotRunning is returned by Resize when the pool is not currently running.
+var ErrNotRunning = errors.New("workerpool: pool is not running")
+
+// Autoscaler adjusts the number of Workers of a running pool between
+// MinWorkers and MaxWorkers based on the depth of the Task's Input and the
+// observed Task latency.
+//
+// The queue depth is only visible for a buffered Input channel; with an
+// unbuffered one the Autoscaler can only see whether an Input is waiting for a
+// free Worker.
+type Autoscaler struct {
+	MinWorkers int
+	MaxWorkers int
+
+	// Interval is how often the Autoscaler re-evaluates the pool size. It
+	// defaults to one second.
+	Interval time.Duration
+
+	// MaxLatency is the average Task latency above which the downstream is
+	// considered saturated, so the Autoscaler sheds a Worker instead of adding
+	// more. Zero ignores latency.
+	MaxLatency time.Duration
+}
+
+func (a *Autoscaler) interval() time.Duration {
+	if a.Interval <= 0 {
+		return time.Second
+	}
+
+	return a.Interval
+}
+
+func (a *Autoscaler) clamp(n int) int {
+	if a.MaxWorkers > 0 && n > a.MaxWorkers {
+		n = a.MaxWorkers
+	}
+	if n < a.MinWorkers {
+		n = a.MinWorkers
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	return n
+}
+
+// desired returns the number of Workers the pool should run given the current
+// number of Workers, how many of them are busy, how many Inputs are queued and
+// the average Task latency.
+func (a *Autoscaler) desired(current, busy, queued int, latency time.Duration) int {
+	n := current
+
+	switch {
+	case a.MaxLatency > 0 && latency > a.MaxLatency:
+		n = current - 1
+	case queued > 0:
+		// Add enough Workers to clear the queue within one interval at the
+		// observed latency.
+		extra := queued
+		if latency > 0 {
+			extra = int(math.Ceil(float64(queued) * float64(latency) / float64(a.interval())))
+		}
+		if busy+extra > n {
+			n = busy + extra
+		}
+	case busy < current:
+		// Release half of the idle Workers at a time.
+		n = current - (current-busy+1)/2
+	}
+
+	return a.clamp(n)
+}
+
+// Resize changes the number of Workers of the running pool. Growing starts
+// new Workers immediately; shrinking lets surplus Workers finish their current
+// Input before they exit, so no Input is dropped.
+func (wp *WorkerPool[In, Out]) Resize(n int) error {
+	if n < 1 {
+		return fmt.Errorf("workerpool: invalid number of workers: %d", n)
+	}
+	if wp.PartitionKey != nil {
+		return ErrPartitioned
+	}
+
+	wp.mu.Lock()
+	r := wp.current
+	wp.mu.Unlock()
+
+	if r == nil {
+		return ErrNotRunning
+	}
+
+	return r.resize(n)
+}
+
+// resize sets the target number of Workers, starting new Workers as needed.
+// Surplus Workers exit the next time they look for an Input.
+func (r *run[In, Out]) resize(n int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.drained {
+		return ErrNotRunning
+	}
+
+	r.target = n
+	for ; r.active < n; r.active++ {
+		r.wg.Add(1)
+		w := Worker[In, Out]{run: r, lane: -1}
+		go w.Work()
+	}
+
+	close(r.resized)
+	r.resized = make(chan struct{})
+
+	return nil
+}
+
+// next returns the next dispatched Input for a Worker consuming the given
+// lane, or any Input if lane is -1. It returns false when the Worker should
+// exit, either because dispatching stopped or because the pool was shrunk.
+func (r *run[In, Out]) next(lane int) (job[In], bool) {
+	if lane >= 0 {
+		return r.nextInLane(lane)
+	}
+
+	for {
+		r.mu.Lock()
+		if r.active > r.target {
+			r.active--
+			r.mu.Unlock()
+			return job[In]{}, false
+		}
+		resized := r.resized
+		r.mu.Unlock()
+
+		select {
+		case j, ok := <-r.jobs:
+			if !ok {
+				r.mu.Lock()
+				r.active--
+				r.drained = true
+				r.mu.Unlock()
+			}
+			return j, ok
+		case <-resized:
+		}
+	}
+}
+
+// observe records the duration of a single Task execution in the run's
+// latency histogram and folds it into the average latency used for
+// autoscaling.
+func (r *run[In, Out]) observe(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.histogram.observe(d)
+
+	if r.latency == 0 {
+		r.latency = d
+		return
+	}
+	r.latency = (4*r.latency + d) / 5
+}
+
+// autoscale periodically resizes the run according to the pool's Autoscaler
+// until ctx is done or dispatching stops.
+func (r *run[In, Out]) autoscale(ctx context.Context) {
+	a := r.pool.Autoscale
+
+	for sleep(ctx, r.pool.clock(), a.interval()) {
+		queued := len(r.input)
+		if r.pending.Load() {
+			queued++
+		}
+
+		r.mu.Lock()
+		current, busy, latency := r.target, len(r.inFlight), r.latency
+		r.mu.Unlock()
+
+		if n := a.desired(current, busy, queued, latency); n != current {
+			if err := r.resize(n); err != nil {
+				return
+			}
+		}
+	}
+}
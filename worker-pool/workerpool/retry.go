@@ -0,0 +1,56 @@
+package workerpool
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how a failed Input is re-executed.
+//
+// The delay before attempt n+1 is BaseDelay * 2^(n-1), capped at MaxDelay.
+// Jitter randomizes that fraction of the delay, so a Jitter of 0.5 waits
+// anywhere between 50% and 100% of the computed delay.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times an Input is executed,
+	// including the first attempt. Values below 2 disable retries.
+	MaxAttempts int
+
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Jitter    float64
+
+	// Retryable reports whether err is worth retrying. A nil Retryable
+	// retries every error.
+	Retryable func(err error) bool
+}
+
+// shouldRetry reports whether an Input that failed with err on the given
+// attempt should be executed again.
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// backoff returns the delay to wait after the given failed attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		j := p.Jitter
+		if j > 1 {
+			j = 1
+		}
+		d = time.Duration(float64(d) * (1 - j*rand.Float64()))
+	}
+
+	return d
+}
@@ -0,0 +1,98 @@
+package schedule_test
+
+import (
+	"golang-library/worker-pool/workerpool/schedule"
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2024, 1, 10, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		{expr: "* * * * *", want: []time.Time{
+			time.Date(2024, 1, 10, 10, 8, 0, 0, time.UTC),
+			time.Date(2024, 1, 10, 10, 9, 0, 0, time.UTC),
+		}},
+		{expr: "*/15 * * * *", want: []time.Time{
+			time.Date(2024, 1, 10, 10, 15, 0, 0, time.UTC),
+			time.Date(2024, 1, 10, 10, 30, 0, 0, time.UTC),
+		}},
+		{expr: "30 2 * * *", want: []time.Time{
+			time.Date(2024, 1, 11, 2, 30, 0, 0, time.UTC),
+			time.Date(2024, 1, 12, 2, 30, 0, 0, time.UTC),
+		}},
+		{expr: "0 9-17/4 * * mon-fri", want: []time.Time{
+			time.Date(2024, 1, 10, 13, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 10, 17, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 11, 9, 0, 0, 0, time.UTC),
+		}},
+		{expr: "0 0 1,15 * *", want: []time.Time{
+			time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		// Day of month or day of week when both are restricted.
+		{expr: "0 0 13 * 5", want: []time.Time{
+			time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC),
+		}},
+		{expr: "0 12 * * 7", want: []time.Time{
+			time.Date(2024, 1, 14, 12, 0, 0, 0, time.UTC),
+		}},
+		{expr: "0 0 29 feb *", want: []time.Time{
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		}},
+		{expr: "@monthly", want: []time.Time{
+			time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{expr: "@every 90m", want: []time.Time{
+			from.Add(90 * time.Minute),
+			from.Add(180 * time.Minute),
+		}},
+		{expr: "0 0 30 2 *", want: []time.Time{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := schedule.Cron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			at := from
+			for i, want := range tt.want {
+				at = s.Next(at)
+				if !at.Equal(want) {
+					t.Fatalf("run %d: got %s, want %s", i, at, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every soon",
+		"@every -1m",
+	} {
+		if _, err := schedule.Cron(expr); err == nil {
+			t.Errorf("%q: got no error", expr)
+		}
+	}
+}
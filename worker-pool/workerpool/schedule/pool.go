@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"golang-library/worker-pool/workerpool"
+)
+
+// PoolJob returns a Job that, at every scheduled time, fetches the Inputs of
+// the run with inputs and executes them with wp. For the duration of each run
+// the pool's Task.Input is replaced by a channel carrying the fetched Inputs
+// and its TotalInput is set to their number.
+//
+// The pool's Results, if set, receives the Results of every run. Unlike with
+// a single Run, it is never closed, and Results of a run whose context is
+// done are dropped if they are not received.
+//
+// A run fails if the Inputs cannot be fetched or any Input fails. Since a Job
+// never overlaps itself, wp is only ever running once; it must not be used by
+// anything else while the Scheduler runs.
+func PoolJob[In, Out any](name string, schedule Schedule, wp *workerpool.WorkerPool[In, Out], inputs func(ctx context.Context) ([]In, error)) Job {
+	return Job{
+		Name:     name,
+		Schedule: schedule,
+		Run: func(ctx context.Context) error {
+			values, err := inputs(ctx)
+			if err != nil {
+				return fmt.Errorf("unable to fetch inputs: %w", err)
+			}
+
+			input := make(chan In, len(values))
+			for _, v := range values {
+				input <- v
+			}
+			close(input)
+
+			savedInput, savedTotal, savedResults := wp.Task.Input, wp.TotalInput, wp.Results
+			defer func() {
+				wp.Task.Input, wp.TotalInput, wp.Results = savedInput, savedTotal, savedResults
+			}()
+
+			wp.Task.Input = input
+			wp.TotalInput = len(values)
+
+			// Run closes the pool's Results when it returns, so every run gets
+			// its own channel, forwarded to the original one.
+			if savedResults != nil {
+				results := make(chan workerpool.Result[In, Out])
+				wp.Results = results
+
+				forwarded := make(chan struct{})
+				go func() {
+					defer close(forwarded)
+					forward(ctx, results, savedResults)
+				}()
+				defer func() { <-forwarded }()
+			}
+
+			_, err = wp.Run(ctx)
+
+			return err
+		},
+	}
+}
+
+// forward sends the values received from in on out until in is closed. Once
+// ctx is done, values that out does not accept are dropped.
+func forward[T any](ctx context.Context, in <-chan T, out chan<- T) {
+	for v := range in {
+		select {
+		case out <- v:
+		case <-ctx.Done():
+		}
+	}
+}
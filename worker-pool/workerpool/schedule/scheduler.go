@@ -0,0 +1,271 @@
+// Package schedule runs jobs, typically WorkerPools, at the times given by
+// cron expressions or fixed intervals, in place of wrapping them in cron
+// scripts:
+//
+//	var s schedule.Scheduler
+//	err := s.Add(schedule.PoolJob("fetch-data", schedule.MustCron("*/15 * * * *"), &wp, fetchDataForProcessing))
+//	err = s.Add(schedule.Job{Name: "cleanup", Schedule: schedule.Every(time.Hour), Run: cleanup})
+//	err = s.Run(ctx)
+//
+// A job never overlaps itself: a run that is due while the previous run of
+// the same job is still going is skipped. The outcome and duration of the
+// last run of every job are available from Status.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-library/worker-pool/workerpool"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDuplicateJob is returned when adding a Job whose name is taken.
+	ErrDuplicateJob = errors.New("schedule: duplicate job name")
+
+	// ErrRunning is returned when running a Scheduler that is already
+	// running.
+	ErrRunning = errors.New("schedule: scheduler is already running")
+
+	// ErrStopping is returned when adding a Job while Run waits for the runs
+	// in progress to return.
+	ErrStopping = errors.New("schedule: scheduler is stopping")
+)
+
+// Job is a named unit of work run on a Schedule.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// Status describes the runs of a Job so far.
+type Status struct {
+	Name    string
+	Running bool
+
+	// Next is when the Job is due next, or the zero time if it will not run
+	// again.
+	Next time.Time
+
+	// LastStart, LastDuration and LastErr describe the last completed run.
+	LastStart    time.Time
+	LastDuration time.Duration
+	LastErr      error
+
+	Runs     int
+	Failures int
+
+	// Skipped is the number of runs skipped because the previous run was
+	// still going.
+	Skipped int
+}
+
+// Scheduler runs Jobs on their Schedules. The zero value is ready to use.
+type Scheduler struct {
+	// Clock tells the time and waits for runs to be due. It defaults to
+	// workerpool.RealClock.
+	Clock workerpool.Clock
+
+	// OnRun, if set, is called with a Job's Status after each of its runs.
+	OnRun func(s Status)
+
+	mu       sync.Mutex
+	jobs     map[string]*entry
+	ctx      context.Context
+	stopping bool
+	wg       sync.WaitGroup
+}
+
+type entry struct {
+	job    Job
+	status Status
+}
+
+// Add schedules job. Jobs can be added before or while the Scheduler runs,
+// but not once its context is done and it is stopping.
+func (s *Scheduler) Add(job Job) error {
+	switch {
+	case job.Name == "":
+		return errors.New("schedule: job has no name")
+	case job.Schedule == nil:
+		return fmt.Errorf("schedule: job %q has no schedule", job.Name)
+	case job.Run == nil:
+		return fmt.Errorf("schedule: job %q has nothing to run", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopping {
+		return fmt.Errorf("%w: %q", ErrStopping, job.Name)
+	}
+	if s.jobs == nil {
+		s.jobs = make(map[string]*entry)
+	}
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateJob, job.Name)
+	}
+
+	e := &entry{job: job, status: Status{Name: job.Name}}
+	s.jobs[job.Name] = e
+
+	if s.ctx != nil {
+		s.wg.Add(1)
+		go s.loop(s.ctx, e)
+	}
+
+	return nil
+}
+
+// Run runs the scheduled Jobs until ctx is done, then waits for the runs in
+// progress, which see ctx cancelled, to return. It returns ctx's error.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.ctx != nil {
+		s.mu.Unlock()
+		return ErrRunning
+	}
+	s.ctx = ctx
+	for _, e := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, e)
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	// Add must not start another loop while waiting for the running ones.
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	s.mu.Lock()
+	s.ctx = nil
+	s.stopping = false
+	s.mu.Unlock()
+
+	return ctx.Err()
+}
+
+// Status returns the Status of the Job with the given name.
+func (s *Scheduler) Status(name string) (Status, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.jobs[name]
+	if !ok {
+		return Status{}, false
+	}
+
+	return e.status, true
+}
+
+// Statuses returns the Status of every Job, sorted by name.
+func (s *Scheduler) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		statuses = append(statuses, e.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	return statuses
+}
+
+// loop starts the runs of e's Job whenever they are due until ctx is done.
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+
+	clock := s.clock()
+	now := clock.Now()
+	next := e.job.Schedule.Next(now)
+
+	for {
+		s.mu.Lock()
+		e.status.Next = next
+		s.mu.Unlock()
+
+		if next.IsZero() || !sleep(ctx, clock, next.Sub(now)) {
+			return
+		}
+
+		s.start(ctx, e)
+
+		// Runs missed while the clock jumped ahead are skipped, not caught up.
+		now = clock.Now()
+		if next = e.job.Schedule.Next(next); !next.IsZero() && next.Before(now) {
+			next = e.job.Schedule.Next(now)
+		}
+	}
+}
+
+// start runs e's Job in the background unless its previous run is still
+// going.
+func (s *Scheduler) start(ctx context.Context, e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.status.Running {
+		e.status.Skipped++
+		return
+	}
+	e.status.Running = true
+
+	s.wg.Add(1)
+	go s.run(ctx, e)
+}
+
+// run runs e's Job once and records its outcome.
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+
+	clock := s.clock()
+	start := clock.Now()
+	err := e.job.Run(ctx)
+	duration := clock.Now().Sub(start)
+
+	s.mu.Lock()
+	e.status.Running = false
+	e.status.LastStart = start
+	e.status.LastDuration = duration
+	e.status.LastErr = err
+	e.status.Runs++
+	if err != nil {
+		e.status.Failures++
+	}
+	status := e.status
+	s.mu.Unlock()
+
+	if s.OnRun != nil {
+		s.OnRun(status)
+	}
+}
+
+func (s *Scheduler) clock() workerpool.Clock {
+	if s.Clock == nil {
+		return workerpool.RealClock
+	}
+
+	return s.Clock
+}
+
+// sleep waits for d on c, returning false if ctx is done first.
+func sleep(ctx context.Context, c workerpool.Clock, d time.Duration) bool {
+	t := c.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,200 @@
+package schedule_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/schedule"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	errBoom := errors.New("boom")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := workerpooltest.NewClock(start)
+
+	runs := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+	job := schedule.Job{
+		Name:     "job",
+		Schedule: schedule.Every(time.Minute),
+		Run: func(ctx context.Context) error {
+			calls++
+			runs <- struct{}{}
+			<-release
+			if calls == 2 {
+				return errBoom
+			}
+			return nil
+		},
+	}
+
+	ran := make(chan schedule.Status)
+	s := schedule.Scheduler{
+		Clock: clock,
+		OnRun: func(st schedule.Status) { ran <- st },
+	}
+	if err := s.Add(job); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(job); !errors.Is(err, schedule.ErrDuplicateJob) {
+		t.Fatalf("got %v, want ErrDuplicateJob", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	tick := func() {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+	}
+
+	// First run, which is still going when the second one is due.
+	tick()
+	<-runs
+	tick()
+	clock.BlockUntil(1)
+	if st, _ := s.Status("job"); !st.Running || st.Skipped != 1 {
+		t.Fatalf("got %+v, want a running job with one skipped run", st)
+	}
+	release <- struct{}{}
+	st := <-ran
+	if st.Runs != 1 || st.LastErr != nil || !st.LastStart.Equal(start.Add(time.Minute)) {
+		t.Errorf("got %+v after the first run", st)
+	}
+
+	// Second run, which fails and takes 30 seconds.
+	tick()
+	<-runs
+	clock.Advance(30 * time.Second)
+	release <- struct{}{}
+	st = <-ran
+	if st.Runs != 2 || st.Failures != 1 || !errors.Is(st.LastErr, errBoom) || st.LastDuration != 30*time.Second {
+		t.Errorf("got %+v after the second run", st)
+	}
+	if want := start.Add(4 * time.Minute); !st.Next.Equal(want) {
+		t.Errorf("next run at %s, want %s", st.Next, want)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if statuses := s.Statuses(); len(statuses) != 1 || statuses[0].Running {
+		t.Errorf("got %+v", statuses)
+	}
+}
+
+func TestPoolJob(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	script := workerpooltest.NewScript[int](workerpooltest.Succeed(1))
+	results := make(chan workerpool.Result[int, int], 4)
+	wp := workerpool.WorkerPool[int, int]{
+		NumberOfWorkers: 3,
+		Task:            workerpool.Task[int, int]{Execute: script.Execute},
+		Results:         results,
+	}
+
+	batch := 0
+	ran := make(chan schedule.Status)
+	s := schedule.Scheduler{Clock: clock, OnRun: func(st schedule.Status) { ran <- st }}
+	err := s.Add(schedule.PoolJob("pool", schedule.Every(time.Hour), &wp, func(ctx context.Context) ([]int, error) {
+		batch++
+		return []int{batch * 10, batch*10 + 1}, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+		if st := <-ran; st.LastErr != nil {
+			t.Fatal(st.LastErr)
+		}
+	}
+	cancel()
+	<-done
+
+	workerpooltest.AssertSameElements(t, script.Started(), []int{10, 11, 20, 21})
+	if wp.Task.Input != nil || wp.TotalInput != 0 || wp.Results != results {
+		t.Error("the pool's Input and Results were not restored")
+	}
+
+	// Both runs forward to the same, still open, Results.
+	var got []int
+	for i := 0; i < 4; i++ {
+		got = append(got, (<-results).Input)
+	}
+	workerpooltest.AssertSameElements(t, got, []int{10, 11, 20, 21})
+}
+
+func TestSchedulerStopping(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := schedule.Scheduler{Clock: clock}
+	err := s.Add(schedule.Job{
+		Name:     "slow",
+		Schedule: schedule.Every(time.Minute),
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	<-started
+	cancel()
+
+	// Jobs added before Run notices ctx is done are accepted, and return at
+	// once.
+	late := func(i int) schedule.Job {
+		return schedule.Job{
+			Name:     fmt.Sprintf("late-%d", i),
+			Schedule: schedule.Every(time.Minute),
+			Run:      func(ctx context.Context) error { return nil },
+		}
+	}
+	for i := 0; ; i++ {
+		err := s.Add(late(i))
+		if errors.Is(err, schedule.ErrStopping) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	close(release)
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if err := s.Add(late(-1)); err != nil {
+		t.Errorf("adding after Run returned: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package workerpool
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the latency histogram buckets
+// used when a pool does not set LatencyBuckets.
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Stats is a point-in-time snapshot of a pool's progress.
+type Stats struct {
+	// Total is the pool's TotalInput, or zero if it is unknown.
+	Total int
+
+	Workers   int
+	Processed int
+	Failed    int
+	InFlight  int
+
+	// Panics is the number of Task executions, retries included, that
+	// panicked.
+	Panics int
+
+	// Breaker is the state of the pool's CircuitBreaker, and ShortCircuited
+	// the number of executions it short-circuited. Without a CircuitBreaker
+	// the state is always BreakerClosed.
+	Breaker        BreakerState
+	ShortCircuited int
+
+	// Queued is the number of Inputs waiting to be picked up by a Worker. It
+	// only includes values buffered in the Task's Input channel or in the
+	// lanes of a partitioned pool, and the Input currently held by the
+	// dispatcher.
+	Queued int
+
+	// Latency is the distribution of Task execution times, retries included.
+	Latency LatencyHistogram
+
+	// Elapsed is the time since the pool started running, and Throughput the
+	// number of processed Inputs per second over that time.
+	Elapsed    time.Duration
+	Throughput float64
+}
+
+// LatencyHistogram counts observed latencies into buckets. Counts[i] holds the
+// observations no greater than Bounds[i] and greater than Bounds[i-1]; the
+// final element of Counts holds the observations greater than every bound.
+type LatencyHistogram struct {
+	Bounds []time.Duration
+	Counts []int
+	Count  int
+	Sum    time.Duration
+}
+
+func newLatencyHistogram(bounds []time.Duration) LatencyHistogram {
+	if bounds == nil {
+		bounds = DefaultLatencyBuckets
+	}
+
+	return LatencyHistogram{
+		Bounds: bounds,
+		Counts: make([]int, len(bounds)+1),
+	}
+}
+
+func (h *LatencyHistogram) observe(d time.Duration) {
+	i := 0
+	for i < len(h.Bounds) && d > h.Bounds[i] {
+		i++
+	}
+
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+// clone returns a copy of h that does not share its Counts.
+func (h LatencyHistogram) clone() LatencyHistogram {
+	h.Counts = append([]int(nil), h.Counts...)
+
+	return h
+}
+
+// Mean returns the average observed latency.
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns the upper bound of the bucket containing the q-th quantile
+// (0 < q <= 1) of the observed latencies. Observations beyond the last bound
+// are reported as the last bound.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 || len(h.Bounds) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(q * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	seen := 0
+	for i, c := range h.Counts[:len(h.Bounds)] {
+		seen += c
+		if seen >= rank {
+			return h.Bounds[i]
+		}
+	}
+
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// StatsReporter receives periodic Stats snapshots from a running pool.
+type StatsReporter interface {
+	Report(s Stats)
+}
+
+// StatsReporterFunc adapts an ordinary function to a StatsReporter.
+type StatsReporterFunc func(s Stats)
+
+// Report calls f(s).
+func (f StatsReporterFunc) Report(s Stats) {
+	f(s)
+}
+
+// Stats returns a snapshot of the running pool, or of its last run once Run
+// has returned. It returns the zero Stats if the pool has never run.
+func (wp *WorkerPool[In, Out]) Stats() Stats {
+	wp.mu.Lock()
+	r := wp.latest
+	wp.mu.Unlock()
+
+	if r == nil {
+		return Stats{}
+	}
+
+	return r.stats()
+}
+
+func (r *run[In, Out]) stats() Stats {
+	queued := len(r.input)
+	if r.pending.Load() {
+		queued++
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsed := r.pool.clock().Now().Sub(r.started)
+	if !r.finished.IsZero() {
+		elapsed = r.finished.Sub(r.started)
+	}
+
+	s := Stats{
+		Total:     r.pool.TotalInput,
+		Workers:   r.target,
+		Processed: int(r.processed.Load()),
+		Failed:    len(r.failures),
+		InFlight:  len(r.inFlight),
+		Panics:    int(r.panics.Load()),
+		Queued:    queued + r.laneQueued(),
+		Latency:   r.histogram.clone(),
+		Elapsed:   elapsed,
+
+		ShortCircuited: int(r.shortCircuited.Load()),
+	}
+	if r.pool.Breaker != nil {
+		s.Breaker = r.pool.Breaker.stateAt(r.pool.clock().Now())
+	}
+	if r.drained {
+		s.Workers = r.active
+	}
+	if elapsed > 0 {
+		s.Throughput = float64(s.Processed) / elapsed.Seconds()
+	}
+
+	return s
+}
+
+// reportStats calls the pool's Reporter every ReportInterval until ctx is done.
+func (r *run[In, Out]) reportStats(ctx context.Context) {
+	interval := r.pool.ReportInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	for sleep(ctx, r.pool.clock(), interval) {
+		r.pool.Reporter.Report(r.stats())
+	}
+}
@@ -0,0 +1,61 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTaskTimeout is recorded for an Input whose Execute did not return within
+// the Task's timeout. Such errors also match context.DeadlineExceeded.
+var ErrTaskTimeout = errors.New("workerpool: task timed out")
+
+// taskTimeout returns the per-execution timeout in effect for the run's Task.
+func (r *run[In, Out]) taskTimeout() time.Duration {
+	if r.pool.Task.Timeout > 0 {
+		return r.pool.Task.Timeout
+	}
+
+	return r.pool.TaskTimeout
+}
+
+// attempt executes the Task against in once, bounded by the Task's timeout.
+//
+// Execute runs in its own goroutine when a timeout is set, so a Task that
+// ignores its context cannot hold the Worker past the deadline. Its eventual
+// outcome is discarded.
+func (r *run[In, Out]) attempt(ctx context.Context, in In) (Out, error) {
+	timeout := r.taskTimeout()
+	if timeout <= 0 {
+		return r.safeExecute(ctx, in)
+	}
+
+	attemptCtx, cancel := withTimeout(ctx, r.pool.clock(), timeout)
+	defer cancel()
+
+	type outcome struct {
+		out Out
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		out, err := r.safeExecute(attemptCtx, in)
+		done <- outcome{out: out, err: err}
+	}()
+
+	var o outcome
+	select {
+	case o = <-done:
+	case <-attemptCtx.Done():
+		o.err = attemptCtx.Err()
+	}
+
+	// Only report a timeout if the deadline, rather than the run, ended the
+	// attempt.
+	if errors.Is(o.err, context.DeadlineExceeded) && attemptCtx.Err() != nil && ctx.Err() == nil {
+		o.err = fmt.Errorf("%w after %s: %w", ErrTaskTimeout, timeout, context.DeadlineExceeded)
+	}
+
+	return o.out, o.err
+}
@@ -0,0 +1,68 @@
+package workerpool
+
+import (
+	"errors"
+)
+
+// Worker executes the pool's Task for every Input dispatched to it.
+type Worker[In, Out any] struct {
+	run *run[In, Out]
+
+	// lane is the lane of a partitioned pool the Worker consumes, or -1.
+	lane int
+}
+
+// Work executes the Task against each dispatched Input until dispatching stops
+// or the pool is shrunk.
+func (w *Worker[In, Out]) Work() {
+	defer w.run.wg.Done()
+
+	for {
+		j, ok := w.run.next(w.lane)
+		if !ok {
+			return
+		}
+
+		if !w.run.start(j) {
+			return
+		}
+
+		start := w.run.pool.clock().Now()
+		out, attempts, err := w.execute(j.input)
+		w.run.observe(w.run.pool.clock().Now().Sub(start))
+
+		if !w.run.finish(j, attempts, err) {
+			return
+		}
+
+		w.run.publish(j, Result[In, Out]{Input: j.input, Output: out, Err: err, Attempts: attempts})
+
+		var pe *PanicError
+		if w.run.pool.RestartOnPanic && errors.As(err, &pe) && w.run.replace(w.lane) {
+			return
+		}
+	}
+}
+
+// execute runs the Task against in, retrying failures according to the
+// Task's RetryPolicy. It returns the number of attempts made.
+func (w *Worker[In, Out]) execute(in In) (Out, int, error) {
+	ctx := w.run.execCtx
+	policy := w.run.retryPolicy()
+
+	for attempt := 1; ; attempt++ {
+		if err := w.run.throttle(ctx, in); err != nil {
+			var zero Out
+			return zero, attempt - 1, err
+		}
+
+		out, err := w.run.guardedAttempt(ctx, in)
+		if err == nil || !policy.shouldRetry(attempt, err) {
+			return out, attempt, err
+		}
+
+		if !sleep(ctx, w.run.pool.clock(), policy.backoff(attempt)) {
+			return out, attempt, err
+		}
+	}
+}
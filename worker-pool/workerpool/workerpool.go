@@ -0,0 +1,611 @@
+// Package workerpool provides a generic worker pool that executes a Task
+// concurrently across a resizable set of Workers.
+//
+// Usage:
+//
+//	input := make(chan int)
+//	results := make(chan workerpool.Result[int, string])
+//
+//	wp := workerpool.WorkerPool[int, string]{
+//		NumberOfWorkers: 50,
+//		Task: workerpool.Task[int, string]{
+//			Input:   input,
+//			Execute: workFunction,
+//		},
+//		Results:      results,
+//		DrainTimeout: 10 * time.Second,
+//	}
+//	go wp.Run(ctx)
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDrainTimeout is returned by Run when in-flight Inputs did not finish
+// within the DrainTimeout after the Run context was cancelled.
+var ErrDrainTimeout = errors.New("workerpool: drain timeout exceeded")
+
+// WorkerPool runs NumberOfWorkers Workers against a single Task.
+type WorkerPool[In, Out any] struct {
+	NumberOfWorkers int
+	Task            Task[In, Out]
+
+	// Results receives the outcome of every executed Input. It is optional and
+	// is closed by Run before it returns.
+	Results chan Result[In, Out]
+
+	// DrainTimeout bounds how long in-flight Inputs may keep running once the
+	// Run context is cancelled. Zero waits for them indefinitely.
+	DrainTimeout time.Duration
+
+	// ErrorPolicy decides whether the pool stops after the Task fails. The
+	// zero value is ContinueOnError.
+	ErrorPolicy ErrorPolicy
+
+	// Retry re-executes failed Inputs. It applies unless the Task sets its own
+	// Retry policy.
+	Retry *RetryPolicy
+
+	// TaskTimeout bounds every execution of the Task, retries included,
+	// unless the Task sets its own Timeout. Zero means no timeout.
+	TaskTimeout time.Duration
+
+	// RateLimit throttles Task executions, retries included, across all
+	// Workers. The same RateLimiter may be shared by several pools.
+	RateLimit *RateLimiter
+
+	// KeyRateLimit additionally throttles Inputs that map to the same key
+	// independently of Inputs with other keys.
+	KeyRateLimit *KeyedRateLimiter[In]
+
+	// Breaker, if set, short-circuits Task executions with ErrCircuitOpen
+	// while the downstream they call is failing.
+	Breaker *CircuitBreaker
+
+	// Autoscale, if set, resizes the pool while it runs. NumberOfWorkers is
+	// then the initial size, clamped to the Autoscaler's bounds.
+	Autoscale *Autoscaler
+
+	// TotalInput is the number of Inputs expected, if known. It is only used
+	// to report progress.
+	TotalInput int
+
+	// Reporter, if set, receives a Stats snapshot every ReportInterval
+	// (default five seconds) and once more when Run returns. See
+	// ProgressReporter for the built-in implementations.
+	Reporter       StatsReporter
+	ReportInterval time.Duration
+
+	// LatencyBuckets overrides DefaultLatencyBuckets for the Stats latency
+	// histogram.
+	LatencyBuckets []time.Duration
+
+	// Ordered emits Results in the order their Inputs were received instead of
+	// the order they completed. Inputs still execute concurrently, but at most
+	// ReorderBuffer of them (default four times NumberOfWorkers) may be
+	// dispatched ahead of the oldest Input whose Result has not been emitted.
+	Ordered       bool
+	ReorderBuffer int
+
+	// PartitionKey, if set, routes every Input with the same key to the same
+	// Worker, so Inputs sharing a key are executed one at a time in the order
+	// they were received, while different keys run in parallel. Each of the
+	// NumberOfWorkers lanes buffers up to LaneBuffer Inputs (default 16); a
+	// partitioned pool cannot be resized or autoscaled.
+	PartitionKey func(in In) string
+	LaneBuffer   int
+
+	// RestartOnPanic replaces a Worker with a fresh goroutine after its Task
+	// panics. Panics are always recovered and reported as a PanicError for
+	// the offending Input, whether or not the Worker is restarted.
+	RestartOnPanic bool
+
+	// Clock measures time for the pool. It defaults to RealClock.
+	Clock Clock
+
+	mu      sync.Mutex
+	current *run[In, Out]
+	latest  *run[In, Out]
+}
+
+// Task pairs the Input channel with the function executed for each value it
+// carries.
+//
+// The context passed to Execute is not cancelled together with the Run
+// context, so in-flight Inputs are allowed to finish. It is cancelled once the
+// pool's DrainTimeout expires, the pool's ErrorPolicy stops the pool, or Run
+// returns, and carries a deadline when a timeout is set.
+type Task[In, Out any] struct {
+	Input   <-chan In
+	Execute func(ctx context.Context, in In) (Out, error)
+
+	// Retry overrides the pool's Retry policy for this Task.
+	Retry *RetryPolicy
+
+	// Timeout overrides the pool's TaskTimeout for this Task. Executions that
+	// exceed it fail with ErrTaskTimeout, which the Retry policy may retry.
+	Timeout time.Duration
+
+	// source, if set, replaces Input with a channel opened for every Run.
+	source source[In]
+}
+
+// source feeds the Input of a Task, such as the one returned by BatchTask,
+// from values it may hold back for a while.
+type source[In any] interface {
+	// open starts feeding the returned channel for a single Run, measuring
+	// time on clock. The returned function stops it and returns the values it
+	// held back.
+	open(clock Clock) (<-chan In, func() []In)
+}
+
+// Result is the outcome of executing a Task against a single Input value.
+type Result[In, Out any] struct {
+	Input    In
+	Output   Out
+	Err      error
+	Attempts int
+}
+
+// Report summarizes a completed Run.
+type Report[In any] struct {
+	// Processed is the number of Inputs the Task was executed against.
+	Processed int
+
+	// Unprocessed lists the Inputs that were received by the pool but never
+	// executed, or were still executing when the DrainTimeout expired.
+	Unprocessed []In
+
+	// Failures lists every Input the Task returned an error for, in dispatch
+	// order.
+	Failures []Failure[In]
+}
+
+// Run starts the Workers and blocks until the Task's Input has been closed and
+// fully consumed, until ctx is cancelled, or until the ErrorPolicy stops the
+// pool.
+//
+// Cancelling ctx stops dispatching new Inputs; Inputs already handed to a
+// Worker are given DrainTimeout to finish. Values still buffered in the Input
+// channel are collected into the Report's Unprocessed list, so producers
+// should stop sending once ctx is done.
+//
+// The returned error joins the reason the pool stopped early, if any
+// (ctx.Err(), ErrTooManyFailures or ErrDrainTimeout), with the Report's
+// Failures. It is nil when every Input was executed successfully.
+func (wp *WorkerPool[In, Out]) Run(ctx context.Context) (Report[In], error) {
+	runCtx, stopRun := context.WithCancel(ctx)
+	defer stopRun()
+
+	execCtx, cancelExec := context.WithCancel(context.Background())
+	defer cancelExec()
+
+	input, stopInput := wp.Task.Input, func() []In { return nil }
+	if wp.Task.source != nil {
+		input, stopInput = wp.Task.source.open(wp.clock())
+	}
+
+	r := &run[In, Out]{
+		pool:    wp,
+		ctx:     runCtx,
+		execCtx: execCtx,
+		input:   input,
+		stop: func() {
+			stopRun()
+			cancelExec()
+		},
+		jobs:      make(chan job[In]),
+		abandoned: make(chan struct{}),
+		resized:   make(chan struct{}),
+		inFlight:  make(map[uint64]In),
+		histogram: newLatencyHistogram(wp.LatencyBuckets),
+		started:   wp.clock().Now(),
+	}
+	if wp.Ordered && wp.Results != nil {
+		r.order = newReorderer[In, Out](wp.reorderBuffer())
+	}
+
+	size := wp.NumberOfWorkers
+	if wp.Autoscale != nil && wp.PartitionKey == nil {
+		size = wp.Autoscale.clamp(size)
+	}
+	if size < 1 {
+		size = 1
+	}
+	if wp.PartitionKey != nil {
+		r.startLanes(size)
+	} else {
+		_ = r.resize(size)
+	}
+
+	wp.mu.Lock()
+	wp.current = r
+	wp.latest = r
+	wp.mu.Unlock()
+
+	defer func() {
+		wp.mu.Lock()
+		wp.current = nil
+		wp.mu.Unlock()
+	}()
+
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		r.dispatch(runCtx)
+	}()
+
+	if wp.Autoscale != nil && wp.PartitionKey == nil {
+		go r.autoscale(runCtx)
+	}
+
+	reported := make(chan struct{})
+	if wp.Reporter != nil {
+		reportCtx, stopReport := context.WithCancel(context.Background())
+		defer func() {
+			stopReport()
+			<-reported
+
+			if p, ok := wp.Reporter.(ProgressReporter); ok {
+				p.Finish(r.stats())
+			} else {
+				wp.Reporter.Report(r.stats())
+			}
+		}()
+
+		go func() {
+			defer close(reported)
+			r.reportStats(reportCtx)
+		}()
+	}
+
+	workersDone := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(workersDone)
+	}()
+
+	var err error
+	select {
+	case <-workersDone:
+	case <-runCtx.Done():
+		var drain <-chan time.Time
+		if wp.DrainTimeout > 0 {
+			t := wp.clock().NewTimer(wp.DrainTimeout)
+			defer t.Stop()
+			drain = t.C()
+		}
+
+		select {
+		case <-workersDone:
+		case <-drain:
+			err = ErrDrainTimeout
+			cancelExec()
+			r.abandon()
+		}
+	}
+
+	<-dispatched
+	held := stopInput()
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil && r.isTripped() {
+		err = ErrTooManyFailures
+	}
+	if err != nil {
+		r.collectBuffered()
+	}
+	r.collectHeld(held)
+
+	r.closeResults()
+
+	r.mu.Lock()
+	r.finished = wp.clock().Now()
+	r.mu.Unlock()
+
+	rep := r.report()
+
+	return rep, errors.Join(err, rep.Err())
+}
+
+// job is a single Input value tagged with its dispatch sequence number.
+type job[In any] struct {
+	seq   uint64
+	input In
+}
+
+// failure is a Failure tagged with the dispatch sequence number of its Input.
+type failure[In any] struct {
+	seq uint64
+	Failure[In]
+}
+
+// run holds the state shared between the dispatcher and Workers of a single
+// call to Run.
+type run[In, Out any] struct {
+	pool    *WorkerPool[In, Out]
+	ctx     context.Context
+	execCtx context.Context
+	input   <-chan In
+	stop    func()
+	jobs    chan job[In]
+	lanes   []chan job[In]
+	order   *reorderer[In, Out]
+
+	processed      atomic.Int64
+	panics         atomic.Int64
+	shortCircuited atomic.Int64
+	pending        atomic.Bool
+
+	wg sync.WaitGroup
+
+	mu            sync.Mutex
+	active        int
+	target        int
+	drained       bool
+	resized       chan struct{}
+	latency       time.Duration
+	histogram     LatencyHistogram
+	started       time.Time
+	finished      time.Time
+	inFlight      map[uint64]In
+	unprocessed   []job[In]
+	failures      []failure[In]
+	tripped       bool
+	abandoned     chan struct{}
+	isAbandoned   bool
+	resultsMu     sync.RWMutex
+	resultsClosed bool
+}
+
+// dispatch forwards values from the Task's Input to the Workers until the
+// Input is closed or ctx is cancelled.
+func (r *run[In, Out]) dispatch(ctx context.Context) {
+	defer func() {
+		close(r.jobs)
+		for _, lane := range r.lanes {
+			close(lane)
+		}
+	}()
+
+	var seq uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case in, ok := <-r.input:
+			if !ok {
+				return
+			}
+
+			j := job[In]{seq: seq, input: in}
+			seq++
+
+			r.pending.Store(true)
+			if !r.handOff(ctx, j) {
+				r.pending.Store(false)
+				r.mu.Lock()
+				r.unprocessed = append(r.unprocessed, j)
+				r.mu.Unlock()
+				return
+			}
+			r.pending.Store(false)
+		}
+	}
+}
+
+// handOff waits for a Worker, or the lane of a partitioned pool, to accept j.
+// With an Ordered pool it first waits for room in the reorder buffer. It
+// returns false if ctx is done first.
+func (r *run[In, Out]) handOff(ctx context.Context, j job[In]) bool {
+	if r.order != nil {
+		select {
+		case r.order.slots <- struct{}{}:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	jobs := r.jobs
+	if r.lanes != nil {
+		jobs = r.laneFor(j.input)
+	}
+
+	select {
+	case jobs <- j:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// collectBuffered drains, without blocking, any values left in the Task's
+// Input or in the lanes of a partitioned pool after dispatching stopped.
+func (r *run[In, Out]) collectBuffered() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectLanes()
+
+	for {
+		select {
+		case in, ok := <-r.input:
+			if !ok {
+				return
+			}
+			r.unprocessed = append(r.unprocessed, job[In]{seq: ^uint64(0), input: in})
+		default:
+			return
+		}
+	}
+}
+
+// collectHeld marks the values held back by the Task's source as unprocessed.
+func (r *run[In, Out]) collectHeld(held []In) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, in := range held {
+		r.unprocessed = append(r.unprocessed, job[In]{seq: ^uint64(0), input: in})
+	}
+}
+
+// abandon gives up on the Inputs still in flight and marks them unprocessed.
+func (r *run[In, Out]) abandon() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.isAbandoned = true
+	r.drained = true
+	close(r.abandoned)
+	for seq, in := range r.inFlight {
+		r.unprocessed = append(r.unprocessed, job[In]{seq: seq, input: in})
+	}
+}
+
+// start records j as in flight. It returns false if the run has been abandoned.
+func (r *run[In, Out]) start(j job[In]) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isAbandoned {
+		r.unprocessed = append(r.unprocessed, j)
+		return false
+	}
+	r.inFlight[j.seq] = j.input
+
+	return true
+}
+
+// finish clears j from the in-flight set and records err as a Failure,
+// stopping the run if the pool's ErrorPolicy limit is reached. It returns
+// false if the run was abandoned while j was executing, in which case its
+// outcome is discarded.
+func (r *run[In, Out]) finish(j job[In], attempts int, err error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isAbandoned {
+		return false
+	}
+	delete(r.inFlight, j.seq)
+	r.processed.Add(1)
+
+	if err != nil {
+		r.failures = append(r.failures, failure[In]{seq: j.seq, Failure: Failure[In]{Input: j.input, Err: err, Attempts: attempts}})
+
+		if !r.tripped && r.pool.ErrorPolicy.tripped(len(r.failures)) {
+			r.tripped = true
+			r.stop()
+		}
+	}
+
+	return true
+}
+
+// throttle blocks until the pool's rate limiters allow in to be executed.
+func (r *run[In, Out]) throttle(ctx context.Context, in In) error {
+	if r.pool.RateLimit != nil {
+		if err := r.pool.RateLimit.wait(ctx, r.pool.clock()); err != nil {
+			return err
+		}
+	}
+
+	if r.pool.KeyRateLimit != nil {
+		if err := r.pool.KeyRateLimit.wait(ctx, in, r.pool.clock()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retryPolicy returns the RetryPolicy in effect for the run's Task.
+func (r *run[In, Out]) retryPolicy() *RetryPolicy {
+	if r.pool.Task.Retry != nil {
+		return r.pool.Task.Retry
+	}
+
+	return r.pool.Retry
+}
+
+func (r *run[In, Out]) isTripped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.tripped
+}
+
+// publish emits the Result of j, holding it back until every earlier Input
+// has been emitted if the pool is Ordered.
+func (r *run[In, Out]) publish(j job[In], res Result[In, Out]) {
+	if r.pool.Results == nil {
+		return
+	}
+
+	if r.order != nil {
+		r.order.push(j.seq, res, r.send)
+		return
+	}
+
+	r.send(res)
+}
+
+// send sends res to the pool's Results unless the run has been abandoned.
+func (r *run[In, Out]) send(res Result[In, Out]) {
+	r.resultsMu.RLock()
+	defer r.resultsMu.RUnlock()
+
+	if r.resultsClosed {
+		return
+	}
+
+	select {
+	case r.pool.Results <- res:
+	case <-r.abandoned:
+	}
+}
+
+// closeResults closes the pool's Results once no Worker can send on it.
+func (r *run[In, Out]) closeResults() {
+	if r.pool.Results == nil {
+		return
+	}
+
+	r.resultsMu.Lock()
+	defer r.resultsMu.Unlock()
+
+	r.resultsClosed = true
+	close(r.pool.Results)
+}
+
+func (r *run[In, Out]) report() Report[In] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sort.SliceStable(r.unprocessed, func(i, j int) bool {
+		return r.unprocessed[i].seq < r.unprocessed[j].seq
+	})
+
+	sort.SliceStable(r.failures, func(i, j int) bool {
+		return r.failures[i].seq < r.failures[j].seq
+	})
+
+	rep := Report[In]{Processed: int(r.processed.Load())}
+	for _, j := range r.unprocessed {
+		rep.Unprocessed = append(rep.Unprocessed, j.input)
+	}
+	for _, f := range r.failures {
+		rep.Failures = append(rep.Failures, f.Failure)
+	}
+
+	return rep
+}
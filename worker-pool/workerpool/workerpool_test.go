@@ -0,0 +1,470 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"golang-library/worker-pool/workerpool"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+func TestRun(t *testing.T) {
+	type fixture struct {
+		script  *workerpooltest.Script[int, int]
+		clock   *workerpooltest.Clock
+		start   time.Time
+		report  workerpool.Report[int]
+		results []workerpool.Result[int, int]
+	}
+
+	tests := []struct {
+		name        string
+		inputs      []int
+		script      *workerpooltest.Script[int, int]
+		configure   func(wp *workerpool.WorkerPool[int, int])
+		autoAdvance bool
+		wantErr     error
+		wantFailed  []int
+		check       func(t *testing.T, f fixture)
+	}{
+		{
+			name:   "processes every input",
+			inputs: workerpooltest.Sequence(50),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)),
+			check: func(t *testing.T, f fixture) {
+				workerpooltest.AssertSameElements(t, workerpooltest.Inputs(f.results), workerpooltest.Sequence(50))
+				workerpooltest.AssertMaxConcurrency(t, f.script, 4)
+			},
+		},
+		{
+			name:   "ordered results",
+			inputs: workerpooltest.Sequence(100),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				wp.Ordered = true
+				wp.ReorderBuffer = 8
+			},
+			check: func(t *testing.T, f fixture) {
+				workerpooltest.AssertOrder(t, workerpooltest.Inputs(f.results), workerpooltest.Sequence(100))
+			},
+		},
+		{
+			name:   "partitioned by key",
+			inputs: workerpooltest.Sequence(100),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				wp.PartitionKey = mod3
+			},
+			check: func(t *testing.T, f fixture) {
+				workerpooltest.AssertKeyOrder(t, f.script.Started(), workerpooltest.Sequence(100), mod3)
+				workerpooltest.AssertKeyOrder(t, f.script.Finished(), workerpooltest.Sequence(100), mod3)
+			},
+		},
+		{
+			name:   "continues on error",
+			inputs: workerpooltest.Sequence(20),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)).
+				On(3, workerpooltest.Fail[int](errBoom)).
+				On(7, workerpooltest.Fail[int](errBoom)),
+			wantErr:    errBoom,
+			wantFailed: []int{3, 7},
+			check: func(t *testing.T, f fixture) {
+				if f.report.Processed != 20 {
+					t.Errorf("got %d processed, want 20", f.report.Processed)
+				}
+			},
+		},
+		{
+			name:   "stops on first error",
+			inputs: workerpooltest.Sequence(20),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)).
+				On(2, workerpooltest.Fail[int](errBoom)),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				wp.NumberOfWorkers = 1
+				wp.ErrorPolicy = workerpool.StopOnFirstError
+			},
+			wantErr:    workerpool.ErrTooManyFailures,
+			wantFailed: []int{2},
+			check: func(t *testing.T, f fixture) {
+				if n := f.report.Processed + len(f.report.Unprocessed); n != 20 {
+					t.Errorf("got %d processed and unprocessed inputs, want 20", n)
+				}
+				if calls := f.script.Calls(19); calls != 0 {
+					t.Errorf("input 19 was executed %d times after the pool stopped", calls)
+				}
+			},
+		},
+		{
+			name:   "retries with backoff",
+			inputs: workerpooltest.Sequence(10),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)).
+				On(5, workerpooltest.Fail[int](errBoom), workerpooltest.Fail[int](errBoom), workerpooltest.Succeed(1)),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				wp.Retry = &workerpool.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute}
+			},
+			autoAdvance: true,
+			check: func(t *testing.T, f fixture) {
+				if calls := f.script.Calls(5); calls != 3 {
+					t.Errorf("got %d calls, want 3", calls)
+				}
+				if waited := f.clock.Now().Sub(f.start); waited != 3*time.Minute {
+					t.Errorf("waited %s between attempts, want 3m0s", waited)
+				}
+			},
+		},
+		{
+			name:   "gives up after max attempts",
+			inputs: workerpooltest.Sequence(3),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)).
+				On(1, workerpooltest.Fail[int](errBoom)),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				wp.Retry = &workerpool.RetryPolicy{MaxAttempts: 4, BaseDelay: time.Second}
+			},
+			autoAdvance: true,
+			wantErr:     errBoom,
+			wantFailed:  []int{1},
+			check: func(t *testing.T, f fixture) {
+				if attempts := f.report.Failures[0].Attempts; attempts != 4 {
+					t.Errorf("got %d attempts, want 4", attempts)
+				}
+			},
+		},
+		{
+			name:   "times out hanging tasks",
+			inputs: workerpooltest.Sequence(3),
+			script: workerpooltest.NewScript[int](workerpooltest.Hang[int]()),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				wp.TaskTimeout = time.Second
+			},
+			autoAdvance: true,
+			wantErr:     workerpool.ErrTaskTimeout,
+			wantFailed:  []int{0, 1, 2},
+			check: func(t *testing.T, f fixture) {
+				if !errors.Is(f.report.Err(), context.DeadlineExceeded) {
+					t.Errorf("got %v, want context.DeadlineExceeded", f.report.Err())
+				}
+			},
+		},
+		{
+			name:   "recovers panics and restarts workers",
+			inputs: workerpooltest.Sequence(10),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)).
+				On(1, workerpooltest.Panic[int](errBoom)).
+				On(6, workerpooltest.Panic[int](errBoom)),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				wp.NumberOfWorkers = 2
+				wp.RestartOnPanic = true
+			},
+			wantErr:    errBoom,
+			wantFailed: []int{1, 6},
+			check: func(t *testing.T, f fixture) {
+				var pe *workerpool.PanicError
+				if !errors.As(f.report.Err(), &pe) {
+					t.Errorf("got %v, want a PanicError", f.report.Err())
+				}
+				if f.report.Processed != 10 {
+					t.Errorf("got %d processed, want 10", f.report.Processed)
+				}
+			},
+		},
+		{
+			name:   "rate limited",
+			inputs: workerpooltest.Sequence(5),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				// A single Worker makes the advancing Clock deterministic.
+				wp.NumberOfWorkers = 1
+				wp.RateLimit = workerpool.NewRateLimiter(2, 1)
+			},
+			autoAdvance: true,
+			check: func(t *testing.T, f fixture) {
+				if waited := f.clock.Now().Sub(f.start); waited != 2*time.Second {
+					t.Errorf("took %s, want 2s", waited)
+				}
+			},
+		},
+		{
+			name:   "rate limited per key",
+			inputs: workerpooltest.Sequence(9),
+			script: workerpooltest.NewScript[int](workerpooltest.Succeed(1)),
+			configure: func(wp *workerpool.WorkerPool[int, int]) {
+				wp.NumberOfWorkers = 1
+				wp.KeyRateLimit = workerpool.NewKeyedRateLimiter(1, 1, mod3)
+			},
+			autoAdvance: true,
+			check: func(t *testing.T, f fixture) {
+				if waited := f.clock.Now().Sub(f.start); waited != 2*time.Second {
+					t.Errorf("took %s, want 2s", waited)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := workerpooltest.NewClock(start)
+			if tt.autoAdvance {
+				defer clock.AutoAdvance()()
+			}
+
+			results := make(chan workerpool.Result[int, int])
+			collected := workerpooltest.Collect(results)
+
+			wp := workerpool.WorkerPool[int, int]{
+				NumberOfWorkers: 4,
+				Task: workerpool.Task[int, int]{
+					Input:   workerpooltest.Feed(tt.inputs...),
+					Execute: tt.script.Execute,
+				},
+				Results: results,
+				Clock:   clock,
+			}
+			if tt.configure != nil {
+				tt.configure(&wp)
+			}
+
+			rep, err := wp.Run(context.Background())
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			var failed []int
+			for _, f := range rep.Failures {
+				failed = append(failed, f.Input)
+			}
+			workerpooltest.AssertOrder(t, failed, tt.wantFailed)
+
+			if tt.check != nil {
+				tt.check(t, fixture{
+					script:  tt.script,
+					clock:   clock,
+					start:   start,
+					report:  rep,
+					results: collected(),
+				})
+			}
+		})
+	}
+}
+
+func TestRunDrainTimeout(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{}, 10)
+	script := workerpooltest.NewScript[int](func(ctx context.Context) (int, error) {
+		started <- struct{}{}
+		return workerpooltest.Block(release, workerpooltest.Succeed(1))(ctx)
+	})
+
+	wp := workerpool.WorkerPool[int, int]{
+		NumberOfWorkers: 2,
+		Task: workerpool.Task[int, int]{
+			Input:   workerpooltest.Feed(workerpooltest.Sequence(10)...),
+			Execute: script.Execute,
+		},
+		DrainTimeout: time.Minute,
+		Clock:        clock,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type outcome struct {
+		rep workerpool.Report[int]
+		err error
+	}
+	done := make(chan outcome)
+	go func() {
+		rep, err := wp.Run(ctx)
+		done <- outcome{rep, err}
+	}()
+
+	<-started
+	<-started
+	cancel()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	o := <-done
+	if !errors.Is(o.err, workerpool.ErrDrainTimeout) {
+		t.Fatalf("got error %v, want ErrDrainTimeout", o.err)
+	}
+	if o.rep.Processed != 0 {
+		t.Errorf("got %d processed, want 0", o.rep.Processed)
+	}
+	if len(o.rep.Unprocessed) != 10 {
+		t.Errorf("got %d unprocessed, want 10", len(o.rep.Unprocessed))
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	defer clock.AutoAdvance()()
+
+	var reports []workerpool.Stats
+	script := workerpooltest.NewScript[int](workerpooltest.Succeed(1)).
+		On(2, workerpooltest.Fail[int](errBoom)).
+		On(4, workerpooltest.Panic[int]("boom"))
+
+	wp := workerpool.WorkerPool[int, int]{
+		NumberOfWorkers: 3,
+		Task: workerpool.Task[int, int]{
+			Input:   workerpooltest.Feed(workerpooltest.Sequence(10)...),
+			Execute: script.Execute,
+		},
+		TotalInput: 10,
+		Reporter: workerpool.StatsReporterFunc(func(s workerpool.Stats) {
+			reports = append(reports, s)
+		}),
+		ReportInterval: time.Hour,
+		Clock:          clock,
+	}
+
+	if _, err := wp.Run(context.Background()); !errors.Is(err, errBoom) {
+		t.Fatalf("got error %v, want %v", err, errBoom)
+	}
+
+	s := wp.Stats()
+	if s.Total != 10 || s.Processed != 10 || s.Failed != 2 || s.Panics != 1 || s.InFlight != 0 {
+		t.Errorf("got %+v", s)
+	}
+	if len(reports) == 0 || reports[len(reports)-1].Processed != 10 {
+		t.Errorf("got reports %+v, want a final report", reports)
+	}
+}
+
+func TestResize(t *testing.T) {
+	wp := workerpool.WorkerPool[int, int]{NumberOfWorkers: 1}
+	if err := wp.Resize(2); !errors.Is(err, workerpool.ErrNotRunning) {
+		t.Errorf("got %v, want ErrNotRunning", err)
+	}
+
+	wp.PartitionKey = mod3
+	if err := wp.Resize(2); !errors.Is(err, workerpool.ErrPartitioned) {
+		t.Errorf("got %v, want ErrPartitioned", err)
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	input := make(chan int)
+	script := workerpooltest.NewScript[int](func(ctx context.Context) (int, error) {
+		started <- struct{}{}
+		return workerpooltest.Block(release, workerpooltest.Succeed(1))(ctx)
+	})
+	wp = workerpool.WorkerPool[int, int]{
+		NumberOfWorkers: 1,
+		Task:            workerpool.Task[int, int]{Input: input, Execute: script.Execute},
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := wp.Run(context.Background())
+		done <- err
+	}()
+
+	input <- 0
+	for wp.Resize(3) != nil {
+		time.Sleep(time.Millisecond)
+	}
+	input <- 1
+	input <- 2
+	close(input)
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := script.MaxConcurrency(); got != 3 {
+		t.Errorf("got %d concurrent executions, want 3", got)
+	}
+}
+
+func TestAutoscale(t *testing.T) {
+	clock := workerpooltest.NewClock(time.Now())
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	input := make(chan int, 10)
+	script := workerpooltest.NewScript[int](func(ctx context.Context) (int, error) {
+		started <- struct{}{}
+		return workerpooltest.Block(release, workerpooltest.Succeed(1))(ctx)
+	})
+
+	wp := workerpool.WorkerPool[int, int]{
+		NumberOfWorkers: 1,
+		Task:            workerpool.Task[int, int]{Input: input, Execute: script.Execute},
+		Autoscale:       &workerpool.Autoscaler{MinWorkers: 2, MaxWorkers: 4, Interval: time.Second},
+		Clock:           clock,
+	}
+	for i := 0; i < 10; i++ {
+		input <- i
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := wp.Run(context.Background())
+		done <- err
+	}()
+
+	// tick lets the Autoscaler evaluate the pool once, returning once it waits
+	// for the next interval, and checks the number of Workers it chose.
+	tick := func(want int) {
+		t.Helper()
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		clock.BlockUntil(1)
+		if got := wp.Stats().Workers; got != want {
+			t.Fatalf("got %d workers, want %d", got, want)
+		}
+	}
+
+	// The pool starts at MinWorkers, both busy with Inputs queued behind
+	// them.
+	for i := 0; i < 2; i++ {
+		<-started
+	}
+	if got := wp.Stats().Workers; got != 2 {
+		t.Fatalf("got %d workers, want 2", got)
+	}
+
+	// The queue grows the pool to MaxWorkers and no further.
+	tick(4)
+	for i := 0; i < 2; i++ {
+		<-started
+	}
+	tick(4)
+
+	// Once the queue has drained, idle Workers are released down to
+	// MinWorkers.
+	close(release)
+	for wp.Stats().Processed != 10 {
+		time.Sleep(time.Millisecond)
+	}
+	tick(2)
+	tick(2)
+
+	close(input)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := script.MaxConcurrency(); got != 4 {
+		t.Errorf("got %d concurrent executions, want 4", got)
+	}
+}
+
+func mod3(i int) string {
+	return strconv.Itoa(i % 3)
+}
@@ -0,0 +1,138 @@
+package workerpooltest
+
+import (
+	"golang-library/worker-pool/workerpool"
+	"testing"
+)
+
+// Feed returns a closed channel buffering values, for use as a Task's Input.
+func Feed[T any](values ...T) <-chan T {
+	c := make(chan T, len(values))
+	for _, v := range values {
+		c <- v
+	}
+	close(c)
+
+	return c
+}
+
+// Sequence returns the integers from 0 to n-1.
+func Sequence(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+
+	return s
+}
+
+// Collect receives every Result from results on a separate goroutine. The
+// returned function blocks until results is closed and returns them in the
+// order they were received.
+func Collect[In, Out any](results <-chan workerpool.Result[In, Out]) func() []workerpool.Result[In, Out] {
+	done := make(chan []workerpool.Result[In, Out], 1)
+	go func() {
+		var all []workerpool.Result[In, Out]
+		for res := range results {
+			all = append(all, res)
+		}
+		done <- all
+	}()
+
+	return func() []workerpool.Result[In, Out] {
+		all := <-done
+		done <- all
+
+		return all
+	}
+}
+
+// Inputs returns the Input of every Result.
+func Inputs[In, Out any](results []workerpool.Result[In, Out]) []In {
+	inputs := make([]In, len(results))
+	for i, res := range results {
+		inputs[i] = res.Input
+	}
+
+	return inputs
+}
+
+// AssertOrder fails t unless got and want hold the same values in the same
+// order.
+func AssertOrder[T comparable](t testing.TB, got, want []T) {
+	t.Helper()
+
+	if !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// AssertSameElements fails t unless got and want hold the same values,
+// counting duplicates, in any order.
+func AssertSameElements[T comparable](t testing.TB, got, want []T) {
+	t.Helper()
+
+	counts := make(map[T]int)
+	for _, v := range want {
+		counts[v]++
+	}
+	for _, v := range got {
+		counts[v]--
+	}
+	for v, n := range counts {
+		if n != 0 {
+			t.Errorf("value %v: got %d more than wanted (got %v, want %v)", v, -n, got, want)
+			return
+		}
+	}
+}
+
+// AssertKeyOrder fails t unless the values in got sharing a key appear in the
+// same relative order as in want. Values with different keys may interleave
+// freely.
+func AssertKeyOrder[T comparable](t testing.TB, got, want []T, key func(v T) string) {
+	t.Helper()
+
+	byKey := func(values []T) map[string][]T {
+		m := make(map[string][]T)
+		for _, v := range values {
+			m[key(v)] = append(m[key(v)], v)
+		}
+		return m
+	}
+
+	gotByKey, wantByKey := byKey(got), byKey(want)
+	for k, w := range wantByKey {
+		if !equal(gotByKey[k], w) {
+			t.Errorf("key %q: got %v, want %v", k, gotByKey[k], w)
+		}
+	}
+	for k, g := range gotByKey {
+		if _, ok := wantByKey[k]; !ok {
+			t.Errorf("key %q: got %v, want none", k, g)
+		}
+	}
+}
+
+func equal[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AssertMaxConcurrency fails t if more than limit executions of s ever ran at
+// the same time.
+func AssertMaxConcurrency[In comparable, Out any](t testing.TB, s *Script[In, Out], limit int) {
+	t.Helper()
+
+	if got := s.MaxConcurrency(); got > limit {
+		t.Errorf("got %d concurrent executions, want at most %d", got, limit)
+	}
+}
@@ -0,0 +1,177 @@
+// Package workerpooltest provides utilities for testing code built on package
+// workerpool: a fake Clock, scripted Task behaviours and assertions on the
+// order and concurrency of executions.
+package workerpooltest
+
+import (
+	"golang-library/worker-pool/workerpool"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is a fake workerpool.Clock whose time only moves when Advance is
+// called. Set it as a pool's Clock to exercise retries, timeouts, rate
+// limiting and drain timeouts without waiting for them.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*timer
+	changed chan struct{}
+}
+
+// NewClock returns a Clock set to start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start, changed: make(chan struct{})}
+}
+
+// Now returns the Clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer returns a Timer that fires once the Clock has been advanced by d.
+func (c *Clock) NewTimer(d time.Duration) workerpool.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{clock: c, deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+
+	c.timers = append(c.timers, t)
+	c.notify()
+
+	return t
+}
+
+// Advance moves the Clock forward by d, firing every timer that becomes due
+// in deadline order.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline.Before(c.timers[j].deadline)
+	})
+
+	n := 0
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			c.timers[n] = t
+			n++
+			continue
+		}
+		t.c <- t.deadline
+	}
+	c.timers = c.timers[:n]
+	c.notify()
+}
+
+// Timers returns the number of timers waiting to fire.
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// BlockUntil blocks until at least n timers are waiting to fire, which is
+// how a test knows that the code under test has started waiting on the Clock.
+func (c *Clock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		if len(c.timers) >= n {
+			c.mu.Unlock()
+			return
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		<-changed
+	}
+}
+
+// AutoAdvance advances the Clock to the next timer's deadline whenever a timer
+// is waiting, so code under test never blocks on the Clock, until the returned
+// function is called.
+func (c *Clock) AutoAdvance() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		for {
+			c.mu.Lock()
+			changed := c.changed
+			var next time.Duration
+			for i, t := range c.timers {
+				if d := t.deadline.Sub(c.now); i == 0 || d < next {
+					next = d
+				}
+			}
+			pending := len(c.timers) > 0
+			c.mu.Unlock()
+
+			if pending {
+				c.Advance(next)
+				continue
+			}
+
+			select {
+			case <-changed:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// notify wakes up goroutines waiting for the timers to change. The caller
+// must hold c.mu.
+func (c *Clock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *Clock) stop(t *timer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.notify()
+			return true
+		}
+	}
+
+	return false
+}
+
+type timer struct {
+	clock    *Clock
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *timer) Stop() bool {
+	return t.clock.stop(t)
+}
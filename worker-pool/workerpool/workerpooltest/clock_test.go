@@ -0,0 +1,96 @@
+package workerpooltest_test
+
+import (
+	"context"
+	"golang-library/worker-pool/workerpool/workerpooltest"
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := workerpooltest.NewClock(start)
+
+	late := c.NewTimer(2 * time.Second)
+	early := c.NewTimer(time.Second)
+	stopped := c.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer returned false")
+	}
+	if n := c.Timers(); n != 2 {
+		t.Fatalf("got %d timers, want 2", n)
+	}
+
+	c.Advance(time.Second)
+	select {
+	case at := <-early.C():
+		if !at.Equal(start.Add(time.Second)) {
+			t.Errorf("fired at %s, want %s", at, start.Add(time.Second))
+		}
+	default:
+		t.Error("due timer did not fire")
+	}
+	select {
+	case <-late.C():
+		t.Error("timer fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	<-late.C()
+	if late.Stop() {
+		t.Error("Stop of a fired timer returned true")
+	}
+	if !c.Now().Equal(start.Add(2 * time.Second)) {
+		t.Errorf("got %s, want %s", c.Now(), start.Add(2*time.Second))
+	}
+}
+
+func TestClockAutoAdvance(t *testing.T) {
+	c := workerpooltest.NewClock(time.Time{})
+	defer c.AutoAdvance()()
+
+	for i := 0; i < 3; i++ {
+		<-c.NewTimer(time.Hour).C()
+	}
+
+	if got := c.Now().Sub(time.Time{}); got != 3*time.Hour {
+		t.Errorf("got %s, want 3h0m0s", got)
+	}
+}
+
+func TestClockBlockUntil(t *testing.T) {
+	c := workerpooltest.NewClock(time.Time{})
+
+	fired := make(chan struct{})
+	go func() {
+		<-c.NewTimer(time.Minute).C()
+		close(fired)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	<-fired
+}
+
+func TestScript(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+
+	s := workerpooltest.NewScript[string](workerpooltest.Succeed(0)).
+		On("a", workerpooltest.Succeed(1), workerpooltest.Block(release, workerpooltest.Succeed(2)))
+
+	for _, want := range []int{1, 2, 2} {
+		if got, err := s.Execute(context.Background(), "a"); err != nil || got != want {
+			t.Errorf("got %d, %v, want %d", got, err, want)
+		}
+	}
+	if got, _ := s.Execute(context.Background(), "b"); got != 0 {
+		t.Errorf("got %d, want the fallback 0", got)
+	}
+
+	workerpooltest.AssertOrder(t, s.Started(), []string{"a", "a", "a", "b"})
+	if s.Calls("a") != 3 || s.MaxConcurrency() != 1 {
+		t.Errorf("got %d calls and %d concurrent executions", s.Calls("a"), s.MaxConcurrency())
+	}
+}
@@ -0,0 +1,163 @@
+package workerpooltest
+
+import (
+	"context"
+	"sync"
+)
+
+// Behavior is how a scripted Task responds to a single execution.
+type Behavior[Out any] func(ctx context.Context) (Out, error)
+
+// Succeed returns out.
+func Succeed[Out any](out Out) Behavior[Out] {
+	return func(context.Context) (Out, error) {
+		return out, nil
+	}
+}
+
+// Fail returns err.
+func Fail[Out any](err error) Behavior[Out] {
+	return func(context.Context) (Out, error) {
+		var zero Out
+		return zero, err
+	}
+}
+
+// Panic panics with v.
+func Panic[Out any](v any) Behavior[Out] {
+	return func(context.Context) (Out, error) {
+		panic(v)
+	}
+}
+
+// Hang blocks until the execution's context is done and returns its error.
+func Hang[Out any]() Behavior[Out] {
+	return func(ctx context.Context) (Out, error) {
+		<-ctx.Done()
+
+		var zero Out
+		return zero, ctx.Err()
+	}
+}
+
+// Block ignores the execution's context and blocks until release is closed,
+// then behaves like then. It stands in for a Task that does not honour
+// cancellation, or holds Workers busy until a test lets them go.
+func Block[Out any](release <-chan struct{}, then Behavior[Out]) Behavior[Out] {
+	return func(ctx context.Context) (Out, error) {
+		<-release
+
+		return then(ctx)
+	}
+}
+
+// Script is a Task whose executions follow scripted Behaviors, recording the
+// order and concurrency in which Inputs were executed. Use its Execute as the
+// Task's Execute:
+//
+//	s := workerpooltest.NewScript[int](workerpooltest.Succeed("ok")).
+//		On(3, workerpooltest.Fail[string](errBoom), workerpooltest.Succeed("ok"))
+//	wp := workerpool.WorkerPool[int, string]{
+//		NumberOfWorkers: 2,
+//		Task: workerpool.Task[int, string]{
+//			Input:   workerpooltest.Feed(1, 2, 3),
+//			Execute: s.Execute,
+//		},
+//	}
+type Script[In comparable, Out any] struct {
+	fallback Behavior[Out]
+
+	mu         sync.Mutex
+	steps      map[In][]Behavior[Out]
+	calls      map[In]int
+	started    []In
+	finished   []In
+	running    int
+	maxRunning int
+}
+
+// NewScript returns a Script that executes every Input without scripted
+// Behaviors with fallback.
+func NewScript[In comparable, Out any](fallback Behavior[Out]) *Script[In, Out] {
+	return &Script[In, Out]{
+		fallback: fallback,
+		steps:    make(map[In][]Behavior[Out]),
+		calls:    make(map[In]int),
+	}
+}
+
+// On scripts the executions of in: the first execution behaves like the first
+// step, the second like the second one and so on, with the last step
+// repeating.
+func (s *Script[In, Out]) On(in In, steps ...Behavior[Out]) *Script[In, Out] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.steps[in] = steps
+
+	return s
+}
+
+// Execute executes in according to the script.
+func (s *Script[In, Out]) Execute(ctx context.Context, in In) (Out, error) {
+	s.mu.Lock()
+	behave := s.fallback
+	if steps := s.steps[in]; len(steps) > 0 {
+		i := s.calls[in]
+		if i >= len(steps) {
+			i = len(steps) - 1
+		}
+		behave = steps[i]
+	}
+	s.calls[in]++
+	s.started = append(s.started, in)
+	s.running++
+	if s.running > s.maxRunning {
+		s.maxRunning = s.running
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.finished = append(s.finished, in)
+		s.mu.Unlock()
+	}()
+
+	return behave(ctx)
+}
+
+// Calls returns the number of times in was executed.
+func (s *Script[In, Out]) Calls(in In) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[in]
+}
+
+// Started returns the Inputs in the order their executions started, with one
+// entry per execution.
+func (s *Script[In, Out]) Started() []In {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]In(nil), s.started...)
+}
+
+// Finished returns the Inputs in the order their executions returned or
+// panicked, with one entry per execution.
+func (s *Script[In, Out]) Finished() []In {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]In(nil), s.finished...)
+}
+
+// MaxConcurrency returns the largest number of executions that were running
+// at the same time.
+func (s *Script[In, Out]) MaxConcurrency() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.maxRunning
+}